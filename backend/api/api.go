@@ -5,16 +5,23 @@ import (
 	"VirtueGaming/api/memory"
 	"VirtueGaming/api/snl"
 	"VirtueGaming/api/ticket"
+	"VirtueGaming/api/tx"
+	"VirtueGaming/utils"
+	"VirtueGaming/utils/indexer"
+	"VirtueGaming/utils/registry"
+	"VirtueGaming/utils/smartcontract"
+	"VirtueGaming/utils/sponsor"
 
 	"github.com/gin-gonic/gin"
 )
 
-func ApplyRoutes(r *gin.Engine) {
+func ApplyRoutes(r *gin.Engine, chain smartcontract.ChainClient, idx *indexer.Client, budget *sponsor.Budget, seeder *utils.TicketSeeder, tickets *registry.Registry) {
 	g := r.Group("/api/v1.0")
 	{
 		ticket.ApplyRoutes(g)
-		game.ApplyRoutes(g)
+		game.ApplyRoutes(g, chain, idx, budget, seeder, tickets)
 		memory.ApplyRoutes(g)
-		snl.ApplyRoutes(g)
+		snl.ApplyRoutes(g, chain, idx)
+		tx.ApplyRoutes(g, chain)
 	}
 }
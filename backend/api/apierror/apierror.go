@@ -0,0 +1,87 @@
+package apierror
+
+import (
+	"VirtueGaming/utils/registry"
+	"VirtueGaming/utils/smartcontract"
+	"VirtueGaming/utils/sponsor"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type chainError struct {
+	err    error
+	status int
+	code   string
+}
+
+// chainErrors maps contract aborts, and the errors of the flows around them,
+// to a HTTP status and a stable error code clients can switch on.
+var chainErrors = []chainError{
+	{smartcontract.ErrSignerNotAdmin, http.StatusForbidden, "SIGNER_NOT_ADMIN"},
+	{smartcontract.ErrNotGameCreator, http.StatusForbidden, "NOT_GAME_CREATOR"},
+	{smartcontract.ErrNotAvatarOwner, http.StatusForbidden, "NOT_AVATAR_OWNER"},
+	{smartcontract.ErrInvalidStartTimestamp, http.StatusBadRequest, "INVALID_START_TIMESTAMP"},
+	{smartcontract.ErrInvalidPrizeName, http.StatusBadRequest, "INVALID_PRIZE_NAME"},
+	{smartcontract.ErrInsufficientBalance, http.StatusPaymentRequired, "INSUFFICIENT_BALANCE"},
+	{smartcontract.ErrGameNotInitialized, http.StatusNotFound, "GAME_NOT_INITIALIZED"},
+	{smartcontract.ErrGameHasStarted, http.StatusConflict, "GAME_HAS_STARTED"},
+	{smartcontract.ErrGameNotStarted, http.StatusConflict, "GAME_NOT_STARTED"},
+	{smartcontract.ErrCantStartGameYet, http.StatusConflict, "CANT_START_GAME_YET"},
+	{smartcontract.ErrGameHasEnded, http.StatusConflict, "GAME_HAS_ENDED"},
+	{smartcontract.ErrNeedToWaitIntervalTime, http.StatusTooManyRequests, "NEED_TO_WAIT_INTERVAL_TIME"},
+	{smartcontract.ErrDuplicatedTicket, http.StatusConflict, "DUPLICATED_TICKET"},
+	{smartcontract.ErrUserAlreadyBoughtOne, http.StatusConflict, "USER_ALREADY_BOUGHT_ONE"},
+	{smartcontract.ErrNotWinningTicket, http.StatusUnprocessableEntity, "NOT_WINNING_TICKET"},
+	{smartcontract.ErrOther, http.StatusUnprocessableEntity, "OTHER"},
+	{smartcontract.ErrInvalidPublicKey, http.StatusBadRequest, "INVALID_PUBLIC_KEY"},
+	{smartcontract.ErrInvalidSignature, http.StatusBadRequest, "INVALID_SIGNATURE"},
+	{smartcontract.ErrCardNotInGame, http.StatusBadRequest, "CARD_NOT_IN_GAME"},
+	{smartcontract.ErrAvatarNotInGame, http.StatusBadRequest, "AVATAR_NOT_IN_GAME"},
+	{sponsor.ErrWalletBudgetExceeded, http.StatusPaymentRequired, "WALLET_SPONSORSHIP_EXCEEDED"},
+	{sponsor.ErrGameBudgetExceeded, http.StatusPaymentRequired, "GAME_SPONSORSHIP_EXCEEDED"},
+	{sponsor.ErrNotReserved, http.StatusConflict, "SPONSORSHIP_NOT_RESERVED"},
+	{sponsor.ErrExpired, http.StatusGone, "SPONSORSHIP_EXPIRED"},
+	{registry.ErrExhausted, http.StatusConflict, "TICKETS_EXHAUSTED"},
+}
+
+// Status returns the HTTP status and error code for err. Errors that are not
+// contract aborts are reported as internal errors.
+func Status(err error) (int, string) {
+	for _, e := range chainErrors {
+		if errors.Is(err, e.err) {
+			return e.status, e.code
+		}
+	}
+	return http.StatusInternalServerError, "INTERNAL"
+}
+
+// internalMessage replaces the text of the errors that are not mapped, which
+// may hold node URLs or SQL.
+const internalMessage = "internal error"
+
+// Respond writes err as a JSON error response. Unmapped errors are logged in
+// full and answered with a generic message.
+func Respond(c *gin.Context, err error) {
+	status, code := Status(err)
+	message := err.Error()
+	if status == http.StatusInternalServerError {
+		logrus.Error("err: ", err)
+		message = internalMessage
+	}
+	c.JSON(status, gin.H{"error": message, "code": code})
+}
+
+// RespondStep writes err as a JSON error response of a multi step flow, step
+// names the stage that failed.
+func RespondStep(c *gin.Context, step string, err error) {
+	status, code := Status(err)
+	logrus.Errorf("%s failed: %s", step, err)
+	message := err.Error()
+	if status == http.StatusInternalServerError {
+		message = internalMessage
+	}
+	c.JSON(status, gin.H{"error": message, "code": code, "step": step})
+}
@@ -0,0 +1,243 @@
+package game
+
+import (
+	"VirtueGaming/api/apierror"
+	"VirtueGaming/config/dbconfig"
+	"VirtueGaming/models"
+	"VirtueGaming/utils"
+	"VirtueGaming/utils/aptos"
+	"VirtueGaming/utils/smartcontract"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// prizes maps the prize names of the API to the names claim_prize expects and
+// the rows of the card, as join_game stored them, that have to be complete.
+var prizes = map[string]struct {
+	name string
+	rows []int
+}{
+	"topLine":    {smartcontract.PrizeTopLine, []int{0}},
+	"middleLine": {smartcontract.PrizeMiddleLine, []int{1}},
+	"bottomLine": {smartcontract.PrizeBottomLine, []int{2}},
+	"fullHouse":  {smartcontract.PrizeFullHouse, []int{0, 1, 2}},
+}
+
+// ClaimPrize verifies a claim against the numbers drawn so far and the owner
+// of the card, and returns the claim_prize transaction for the player to
+// sign. SubmitClaim sends it, the prize is paid out by the next draw.
+func (h *handler) ClaimPrize(c *gin.Context) {
+	var req ClaimPrizeRequest
+	if err := c.BindJSON(&req); err != nil {
+		logrus.Error("failed to bind request: ", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	prize, ok := prizes[req.Prize]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid prize %q", req.Prize), "code": "INVALID_PRIZE_NAME"})
+		return
+	}
+	game, module, err := h.findGame(strconv.Itoa(req.GameId), req.Module)
+	if err != nil {
+		logrus.Error("failed to fetch game: ", err)
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	wallet, err := smartcontract.PlayerAddress(req.PublicKey)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	db := dbconfig.GetDb()
+	var ticket models.Ticket
+	if err := db.Model(&models.Ticket{}).
+		Where("game_id = ? AND module = ? AND wallet_address = ?", game.GameId, module.ID(), wallet).
+		First(&ticket).Error; err != nil {
+		logrus.Error("failed to fetch ticket: ", err)
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if ticket.CardAddress != "" && !aptos.SameAddress(ticket.CardAddress, req.CardAddress) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "card does not belong to the wallet", "code": "CARD_MISMATCH"})
+		return
+	}
+	// claim_prize pays whoever sends it for any card of the game, so the
+	// card has to be the player's.
+	owner, err := h.chain.CardOwner(smartcontract.CardParams{Module: module, GameID: game.GameId, Address: req.CardAddress})
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+	if !aptos.SameAddress(owner, wallet) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "card does not belong to the wallet", "code": "CARD_MISMATCH"})
+		return
+	}
+	format := utils.FormatForType(game.Type)
+	card, err := utils.ParseCard(format, ticket.Ticket)
+	if err != nil {
+		logrus.Error("invalid stored ticket: ", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	rows := format.ChainRows(card)
+
+	var numbers []int
+	if err := db.Model(&models.DrawnNumber{}).
+		Where("game_id = ? AND module = ?", game.GameId, module.ID()).
+		Pluck("number", &numbers).Error; err != nil {
+		logrus.Error("failed to fetch drawn numbers: ", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	drawn := make(map[int]bool, len(numbers))
+	for _, n := range numbers {
+		drawn[n] = true
+	}
+	for _, r := range prize.rows {
+		if !utils.LineDrawn(rows[r], drawn) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "not a winning ticket", "code": "NOT_WINNING_TICKET"})
+			return
+		}
+	}
+
+	tx, err := h.chain.PrepareClaimPrize(smartcontract.ClaimPrizeParams{
+		Module:          module,
+		GameID:          game.GameId,
+		Prize:           prize.name,
+		Address:         req.CardAddress,
+		PlayerPublicKey: req.PublicKey,
+	})
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+	// A claim prepared again replaces the one the player did not sign.
+	claim := models.Claim{
+		GameId:        game.GameId,
+		Module:        module.ID(),
+		WalletAddress: wallet,
+		Prize:         prize.name,
+		Status:        models.ClaimStatusPrepared,
+	}
+	if err := db.Model(&models.Claim{}).
+		Where(&claim).
+		Assign(models.Claim{CardAddress: req.CardAddress, RawTransaction: tx.RawTransaction}).
+		FirstOrCreate(&claim).Error; err != nil {
+		logrus.Error("db err: ", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sender":         tx.Sender,
+		"rawTransaction": tx.RawTransaction,
+		"signingMessage": tx.SigningMessage,
+		"expiresAt":      tx.ExpiresAt,
+	})
+}
+
+// SubmitClaim sends a claim once the player signed it.
+func (h *handler) SubmitClaim(c *gin.Context) {
+	var req SubmitClaimRequest
+	if err := c.BindJSON(&req); err != nil {
+		logrus.Error("failed to bind request: ", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	prize, ok := prizes[req.Prize]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid prize %q", req.Prize), "code": "INVALID_PRIZE_NAME"})
+		return
+	}
+	game, module, err := h.findGame(strconv.Itoa(req.GameId), req.Module)
+	if err != nil {
+		logrus.Error("failed to fetch game: ", err)
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	wallet, err := smartcontract.PlayerAddress(req.PublicKey)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	db := dbconfig.GetDb()
+	prepared := models.Claim{
+		GameId:        game.GameId,
+		Module:        module.ID(),
+		WalletAddress: wallet,
+		Prize:         prize.name,
+		Status:        models.ClaimStatusPrepared,
+	}
+	var claim models.Claim
+	if err := db.Model(&models.Claim{}).Where(&prepared).First(&claim).Error; err != nil {
+		logrus.Error("failed to fetch claim: ", err)
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	// Only one request may send a prepared claim.
+	res := db.Model(&models.Claim{}).Where(&prepared).Update("status", models.ClaimStatusPending)
+	if res.Error != nil {
+		logrus.Error("db err: ", res.Error)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": res.Error.Error()})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "claim is not waiting for a signature", "code": "CLAIM_NOT_PREPARED"})
+		return
+	}
+	pending := prepared
+	pending.Status = models.ClaimStatusPending
+
+	params := smartcontract.ClaimPrizeParams{
+		Module:          module,
+		GameID:          game.GameId,
+		Prize:           prize.name,
+		Address:         claim.CardAddress,
+		PlayerPublicKey: req.PublicKey,
+	}
+	tx, err := h.chain.SubmitSigned(smartcontract.NewClaimTx(params, wallet, claim.RawTransaction), req.PublicKey, req.Signature)
+	if err != nil {
+		// A claim the node never accepted can be signed again, one that
+		// failed on chain has to be prepared again.
+		status := ""
+		switch {
+		case errors.Is(err, smartcontract.ErrInvalidPublicKey) || errors.Is(err, smartcontract.ErrInvalidSignature) ||
+			errors.Is(err, smartcontract.ErrNotSubmitted):
+			status = models.ClaimStatusPrepared
+		case tx != nil:
+			status = models.ClaimStatusFailed
+		}
+		if status != "" {
+			if err := db.Model(&models.Claim{}).Where(&pending).Where("transaction_hash = ?", "").Update("status", status).Error; err != nil {
+				logrus.Error("db err: ", err)
+			}
+		}
+		apierror.Respond(c, err)
+		return
+	}
+	txHash := tx.Result.TransactionHash
+
+	if err := db.Model(&models.Claim{}).Where(&pending).Where("transaction_hash = ?", "").Updates(models.Claim{
+		TransactionHash:    txHash,
+		TransactionVersion: tx.Result.Version,
+	}).Error; err != nil {
+		logrus.Error("db err: ", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "data": txHash})
+		return
+	}
+	if err := db.Model(&models.Ticket{}).
+		Where("game_id = ? AND module = ? AND wallet_address = ? AND card_address = ''", game.GameId, module.ID(), wallet).
+		Update("card_address", claim.CardAddress).Error; err != nil {
+		logrus.Error("db err: ", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": txHash})
+}
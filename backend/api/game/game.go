@@ -1,10 +1,15 @@
 package game
 
 import (
+	"VirtueGaming/api/apierror"
 	"VirtueGaming/config/dbconfig"
 	"VirtueGaming/models"
+	"VirtueGaming/utils"
+	"VirtueGaming/utils/aptos"
+	"VirtueGaming/utils/indexer"
+	"VirtueGaming/utils/registry"
 	"VirtueGaming/utils/smartcontract"
-	"bytes"
+	"VirtueGaming/utils/sponsor"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -15,13 +20,57 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-func ApplyRoutes(r *gin.RouterGroup) {
+// handler holds the dependencies of the routes that talk to the chain.
+type handler struct {
+	chain    smartcontract.ChainClient
+	indexer  *indexer.Client
+	sponsor  *sponsor.Budget
+	seeder   *utils.TicketSeeder
+	registry *registry.Registry
+
+	// renderTicket draws the image of a card, pinImage and pinMetadata pin
+	// files to IPFS and return their hash.
+	renderTicket func(cells [][]string) ([]byte, error)
+	pinImage     func(data []byte) (string, error)
+	pinMetadata  func(data []byte) (string, error)
+}
+
+func ApplyRoutes(r *gin.RouterGroup, chain smartcontract.ChainClient, idx *indexer.Client, budget *sponsor.Budget, seeder *utils.TicketSeeder, tickets *registry.Registry) {
+	h := &handler{
+		chain:    chain,
+		indexer:  idx,
+		sponsor:  budget,
+		seeder:   seeder,
+		registry: tickets,
+		renderTicket: func(cells [][]string) ([]byte, error) {
+			return utils.CreateTicketBytes(cells, "image.png")
+		},
+		pinImage: func(data []byte) (string, error) {
+			return utils.UploadImageToNFTStorage(os.Getenv("NFT_STORAGE_KEY"), data)
+		},
+		pinMetadata: func(data []byte) (string, error) {
+			return utils.UploadMetadataToNFTStorage(os.Getenv("NFT_STORAGE_KEY"), data)
+		},
+	}
+	h.routes(r)
+}
+
+func (h *handler) routes(r *gin.RouterGroup) {
 	g := r.Group("/game")
 	{
-		g.POST("", CreateGame)
+		g.POST("", h.CreateGame)
 		g.GET("/all", GetAllGames)
 		g.GET("", GetGameById)
-		g.GET("/drawNumber", DrawNumber)
+		g.GET("/stats", h.GetStats)
+		g.GET("/drawNumber", h.DrawNumber)
+		g.POST("/start", h.StartGame)
+		g.POST("/cancel", h.CancelGame)
+		g.POST("/join", h.JoinGame)
+		g.POST("/join/submit", h.SubmitJoin)
+		g.POST("/claim", h.ClaimPrize)
+		g.POST("/claim/submit", h.SubmitClaim)
+		g.GET("/seed", h.GetSeed)
+		g.POST("/verifyTicket", h.VerifyTicket)
 	}
 }
 
@@ -59,7 +108,37 @@ func GetGameById(c *gin.Context) {
 	c.JSON(http.StatusOK, game)
 
 }
-func CreateGame(c *gin.Context) {
+
+// module parses the module a request targets, the configured one when
+// moduleId is empty.
+func (h *handler) module(moduleId string) (smartcontract.Module, error) {
+	if moduleId == "" {
+		return h.chain.BingoModule(), nil
+	}
+	return smartcontract.ParseModule(moduleId)
+}
+
+// findGame loads a bingo game by its on-chain id. Ids restart with every
+// module version, so moduleId selects the module and defaults to the
+// configured one. Rows stored before the module was recorded match any module.
+func (h *handler) findGame(gameId, moduleId string) (*models.Game, smartcontract.Module, error) {
+	module, err := h.module(moduleId)
+	if err != nil {
+		return nil, module, err
+	}
+	var game models.Game
+	db := dbconfig.GetDb()
+	if err := db.Model(&models.Game{}).Where("game_id = ? AND (module = ? OR module = '')", gameId, module.ID()).First(&game).Error; err != nil {
+		return nil, module, err
+	}
+	return &game, module, nil
+}
+
+// stepPinCollection is the step of creating a game that pins the collection
+// metadata.
+const stepPinCollection = "pin_collection_metadata"
+
+func (h *handler) CreateGame(c *gin.Context) {
 	//create game
 	var req CreateGameRequest
 	err := c.BindJSON(&req)
@@ -68,86 +147,121 @@ func CreateGame(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	tx, err := smartcontract.CallCreateGame(smartcontract.CreateGameParams{GameName: req.Name, StartTimestamp: req.StartTimestamp})
-	if err != nil {
-		logrus.Error("err: ", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-	txHash := tx.Result.TransactionHash
-
-	query := `
-	query MyQuery {
-	  events(
-	    where: {
-	      data: { _contains: { game_name: "%s" } },
-	      type: { _eq: "%s" }
-	    }
-	  ) {
-		data
-	  }
-	}
-`
-	requestBody, err := json.Marshal(map[string]string{
-		"query": fmt.Sprintf(query, req.Name, os.Getenv("APTOS_FUNCTION_ID")+"::bingov1::CreateGameEvent"),
-	})
+	params, err := createGameParams(req)
 	if err != nil {
-		fmt.Println("Error marshalling request body:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": "INVALID_GAME_PARAMS"})
 		return
 	}
-
-	contractReq, err := http.NewRequest(http.MethodPost, "https://indexer.random.aptoslabs.com/v1/graphql", bytes.NewReader(requestBody))
-	if err != nil {
-		logrus.Error("failed to send request: %s", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	if params.CollectionURI == "" {
+		uri, err := h.pinCollectionMetadata(req)
+		if err != nil {
+			apierror.RespondStep(c, stepPinCollection, err)
+			return
+		}
+		params.CollectionURI = uri
 	}
-	client := &http.Client{}
-	resp, err := client.Do(contractReq)
+	module := h.chain.BingoModule()
+	tx, err := h.chain.CreateGame(params)
 	if err != nil {
-		logrus.Error("failed to send request: %s", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		apierror.Respond(c, err)
 		return
 	}
-	if resp.StatusCode != 200 {
-		logrus.Error("Error in response: %s", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-	defer resp.Body.Close()
+	txHash := tx.Result.TransactionHash
 
-	var result Response
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		fmt.Println("Error decoding response body:", err)
+	var event indexer.CreateGameEvent
+	if err := h.indexer.TxEvent(c.Request.Context(), tx, module.EventType("CreateGameEvent"), &event); err != nil {
+		logrus.Errorf("failed to read game id of %s: %s", txHash, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error(), "data": txHash})
 		return
 	}
-	gameId := result.Data.Events[0].Data.GameID
-	gameIdInt, _ := strconv.Atoi(gameId)
+	gameIdInt := event.GameID
+	gameId := strconv.Itoa(gameIdInt)
 	game := models.Game{
-		Name:                 req.Name,
-		StartTimestamp:       req.StartTimestamp,
-		Symbol:               req.Symbol,
-		Picture:              req.Picture,
-		CoverImage:           req.CoverImage,
-		Description:          req.Description,
-		CreatorWalletAddress: req.CreatorWalletAddress,
-		Type:                 req.Type,
-		TransactionHash:      txHash,
-		GameId:               gameIdInt,
+		Name:                  req.Name,
+		StartTimestamp:        req.StartTimestamp,
+		Symbol:                req.Symbol,
+		Picture:               req.Picture,
+		CoverImage:            req.CoverImage,
+		Description:           req.Description,
+		CreatorWalletAddress:  req.CreatorWalletAddress,
+		Type:                  req.Type,
+		TransactionHash:       txHash,
+		MintPrice:             int64(params.MintPrice),
+		Interval:              int64(params.Interval),
+		CollectionName:        params.CollectionName,
+		CollectionDescription: params.CollectionDescription,
+		CollectionUri:         params.CollectionURI,
+		RoyaltyNumerator:      int64(params.RoyaltyNumerator),
 	}
+	// The ingester may have stored the game from its CreateGameEvent already.
 	db := dbconfig.GetDb()
-	if err = db.Model(&models.Game{}).Create(&game).Error; err != nil {
+	if err = db.Model(&models.Game{}).
+		Where(map[string]interface{}{"game_id": gameIdInt, "module": module.ID()}).
+		Attrs(models.Game{Status: models.GameStatusNotStarted}).
+		Assign(game).
+		FirstOrCreate(&game).Error; err != nil {
 		logrus.Error("db err: ", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": txHash, "gameId": gameId})
+	c.JSON(http.StatusOK, gin.H{"data": txHash, "gameId": gameId, "seedCommitment": h.seeder.Commitment(module.ID(), gameIdInt)})
 }
 
-func DrawNumber(c *gin.Context) {
-	gameId := c.Query("gameId")
-	gameIdInt, _ := strconv.Atoi(gameId)
+// createGameParams converts and validates the create_game arguments of req.
+func createGameParams(req CreateGameRequest) (smartcontract.CreateGameParams, error) {
+	p := smartcontract.CreateGameParams{
+		GameName:              req.Name,
+		StartTimestamp:        req.StartTimestamp,
+		Interval:              req.Interval,
+		CollectionName:        req.CollectionName,
+		CollectionDescription: req.CollectionDescription,
+		CollectionURI:         req.CollectionUri,
+		RoyaltyNumerator:      req.RoyaltyNumerator,
+	}
+	if p.CollectionName == "" {
+		p.CollectionName = req.Name
+	}
+	if p.CollectionDescription == "" {
+		p.CollectionDescription = req.Description
+	}
+	if req.MintPrice == "" {
+		return p, fmt.Errorf("mint price is required")
+	}
+	var err error
+	if p.MintPrice, err = aptos.ParseAmount(req.MintPrice); err != nil {
+		return p, err
+	}
+	return p, p.Validate()
+}
+
+// pinCollectionMetadata pins the metadata of the card collection of a game
+// and returns its ipfs:// URI.
+func (h *handler) pinCollectionMetadata(req CreateGameRequest) (string, error) {
+	metadata, err := json.Marshal(models.CollectionMetadata{
+		Name:        req.Name,
+		Symbol:      req.Symbol,
+		Description: req.Description,
+		Image:       req.Picture,
+		CoverImage:  req.CoverImage,
+	})
+	if err != nil {
+		return "", err
+	}
+	hash, err := h.pinMetadata(metadata)
+	if err != nil {
+		return "", err
+	}
+	return "ipfs://" + hash, nil
+}
+
+func (h *handler) DrawNumber(c *gin.Context) {
+	game, module, err := h.findGame(c.Query("gameId"), c.Query("module"))
+	if err != nil {
+		logrus.Error("failed to fetch game: ", err)
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
 	// var req CreateGameRequest
 	// err := c.BindJSON(&req)
 	// if err != nil {
@@ -155,59 +269,156 @@ func DrawNumber(c *gin.Context) {
 	// 	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 
 	// }
-	tx, err := smartcontract.CallDrawNumber(smartcontract.DrawNumberParams{GameID: gameIdInt})
+	tx, err := h.chain.DrawNumber(smartcontract.DrawNumberParams{Module: module, GameID: game.GameId})
 	if err != nil {
-		logrus.Error("err: ", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		apierror.Respond(c, err)
 		return
 	}
 	txHash := tx.Result.TransactionHash
 
-	query := `
-	query MyQuery {
-		events(
-			where: {type: {_eq: "%s"}}
-			order_by: {sequence_number: desc}
-			limit: 1
-		) {
-			data
+	ended, _ := tx.Event(module.EventType("GameEndedEvent"), &struct{}{})
+	if ended {
+		if err := updateGameStatus(game, models.GameStatusFinished); err != nil {
+			logrus.Error("db err: ", err)
 		}
 	}
-`
-	requestBody, err := json.Marshal(map[string]string{
-		"query": fmt.Sprintf(query, os.Getenv("APTOS_FUNCTION_ID")+"::bingov1::DrawNumberEvent"),
-	})
-	if err != nil {
-		fmt.Println("Error marshalling request body:", err)
+	var event indexer.DrawNumberEvent
+	if drew, _ := tx.Event(module.EventType("DrawNumberEvent"), &event); ended && !drew {
+		// A draw after the full house ends the game without drawing a number.
+		c.JSON(http.StatusOK, gin.H{"finished": true, "data": txHash})
+		return
+	}
+	if err := h.indexer.TxEvent(c.Request.Context(), tx, module.EventType("DrawNumberEvent"), &event); err != nil {
+		logrus.Errorf("failed to read drawn number of %s: %s", txHash, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error(), "data": txHash})
+		return
+	}
+	numberInt := event.Number
+	number := strconv.Itoa(numberInt)
+	drawn := models.DrawnNumber{
+		GameId: game.GameId,
+		Module: module.ID(),
+		Number: numberInt,
+	}
+	db := dbconfig.GetDb()
+	if err := db.Model(&models.DrawnNumber{}).
+		Where(map[string]interface{}{"game_id": drawn.GameId, "module": drawn.Module, "number": drawn.Number}).
+		Assign(models.DrawnNumber{TransactionHash: txHash}).
+		FirstOrCreate(&drawn).Error; err != nil {
+		logrus.Error("db err: ", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	contractReq, err := http.NewRequest(http.MethodPost, "https://indexer.random.aptoslabs.com/v1/graphql", bytes.NewReader(requestBody))
+	c.JSON(http.StatusOK, gin.H{"number": number, "finished": ended, "data": txHash})
+}
+
+func (h *handler) StartGame(c *gin.Context) {
+	var req StartGameRequest
+	if err := c.BindJSON(&req); err != nil {
+		logrus.Error("failed to bind request: ", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	game, module, err := h.findGame(strconv.Itoa(req.GameId), req.Module)
 	if err != nil {
-		logrus.Error("failed to send request: %s", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		logrus.Error("failed to fetch game: ", err)
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
-	client := &http.Client{}
-	resp, err := client.Do(contractReq)
+	tx, err := h.chain.StartGame(smartcontract.StartGameParams{Module: module, GameID: game.GameId})
 	if err != nil {
-		logrus.Error("failed to send request: %s", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		apierror.Respond(c, err)
 		return
 	}
-	if resp.StatusCode != 200 {
-		logrus.Error("Error in response: %s", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	txHash := tx.Result.TransactionHash
+	if err := updateGameStatus(game, models.GameStatusStarted); err != nil {
+		logrus.Error("db err: ", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "data": txHash})
 		return
 	}
-	defer resp.Body.Close()
+	c.JSON(http.StatusOK, gin.H{"data": txHash})
+}
 
-	var result DrawNumberResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		fmt.Println("Error decoding response body:", err)
+// CancelGame cancels a game that has not started on behalf of its creator,
+// who proves it by signing smartcontract.CancelMessage with their key.
+func (h *handler) CancelGame(c *gin.Context) {
+	var req CancelGameRequest
+	if err := c.BindJSON(&req); err != nil {
+		logrus.Error("failed to bind request: ", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	number := result.Data.Events[0].Data.Number
+	game, module, err := h.findGame(strconv.Itoa(req.GameId), req.Module)
+	if err != nil {
+		logrus.Error("failed to fetch game: ", err)
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	creator, err := smartcontract.PlayerAddress(req.PublicKey)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+	if !aptos.SameAddress(creator, game.CreatorWalletAddress) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the game creator can cancel the game", "code": "NOT_GAME_CREATOR"})
+		return
+	}
+	if err := smartcontract.VerifyMessage(req.PublicKey, smartcontract.CancelMessage(module, game.GameId), req.FullMessage, req.Signature); err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+	tx, err := h.chain.CancelGame(smartcontract.CancelGameParams{Module: module, GameID: game.GameId})
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+	txHash := tx.Result.TransactionHash
+	if err := updateGameStatus(game, models.GameStatusCancelled); err != nil {
+		logrus.Error("db err: ", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "data": txHash})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": txHash})
+}
 
-	c.JSON(http.StatusOK, gin.H{"number": number, "data": txHash})
+func updateGameStatus(game *models.Game, status string) error {
+	db := dbconfig.GetDb()
+	return db.Model(&models.Game{}).
+		Where("game_id = ? AND module = ?", game.GameId, game.Module).
+		Update("status", status).Error
+}
+
+// GetStats reads the state of a game from the chain.
+func (h *handler) GetStats(c *gin.Context) {
+	gameId, err := strconv.Atoi(c.Query("gameId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid gameId"})
+		return
+	}
+	module, err := h.module(c.Query("module"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	state, err := h.chain.BingoGameState(smartcontract.GameStateParams{Module: module, GameID: gameId})
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+	prizePool := gin.H{"octas": state.PrizePool, "apt": state.PrizePoolAPT()}
+	if price, err := smartcontract.GetCoinPrice(); err != nil {
+		logrus.Error("failed to fetch APT price: ", err)
+	} else {
+		prizePool["usd"] = state.PrizePoolAPT() * price
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"gameId":            gameId,
+		"module":            module.ID(),
+		"cardsSold":         state.CardsSold,
+		"collectionAddress": state.CollectionAddress,
+		"prizePool":         prizePool,
+		"isStarted":         state.IsStarted,
+		"isFinished":        state.IsFinished,
+	})
 }
@@ -0,0 +1,416 @@
+package game
+
+import (
+	"VirtueGaming/config/dbconfig"
+	"VirtueGaming/models"
+	"VirtueGaming/utils"
+	"VirtueGaming/utils/indexer"
+	"VirtueGaming/utils/registry"
+	"VirtueGaming/utils/smartcontract"
+	"VirtueGaming/utils/sponsor"
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const testInterval = 10
+
+// testServer serves the game routes against a FakeChain, a SQLite database
+// and pins that never leave the process.
+type testServer struct {
+	t      *testing.T
+	router *gin.Engine
+	chain  *smartcontract.FakeChain
+	now    time.Time
+}
+
+func newTestServer(t *testing.T) *testServer {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dbconfig.SetDb(db)
+	t.Cleanup(func() { dbconfig.SetDb(nil) })
+	if err := dbconfig.DbInit(); err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	// The fake clock starts now: reservations expire in real time.
+	s := &testServer{t: t, router: gin.New(), chain: smartcontract.NewFakeChain("0xad"), now: time.Now()}
+	s.chain.Now = func() time.Time { return s.now }
+	s.chain.Seed(1)
+	seeder := utils.NewTicketSeeder(make([]byte, 32))
+	pins := 0
+	pin := func(data []byte) (string, error) {
+		pins++
+		return "bafy" + strconv.Itoa(pins), nil
+	}
+	h := &handler{
+		chain:        s.chain,
+		indexer:      indexer.NewClient("http://indexer.invalid"),
+		sponsor:      sponsor.New(db),
+		seeder:       seeder,
+		registry:     registry.New(db, seeder),
+		renderTicket: func(cells [][]string) ([]byte, error) { return []byte("png"), nil },
+		pinImage:     pin,
+		pinMetadata:  pin,
+	}
+	h.routes(s.router.Group(""))
+	return s
+}
+
+// do sends body as JSON, or as the query of a GET, and decodes the response.
+func (s *testServer) do(method, path string, body interface{}) (int, map[string]interface{}) {
+	s.t.Helper()
+	var req *http.Request
+	if method == http.MethodGet {
+		req = httptest.NewRequest(method, path, nil)
+	} else {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			s.t.Fatal(err)
+		}
+		req = httptest.NewRequest(method, path, bytes.NewReader(raw))
+		req.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	var out map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+		s.t.Fatalf("%s %s: invalid response %q", method, path, w.Body.String())
+	}
+	return w.Code, out
+}
+
+// expect sends a request and fails unless it is answered with status.
+func (s *testServer) expect(status int, method, path string, body interface{}) map[string]interface{} {
+	s.t.Helper()
+	code, out := s.do(method, path, body)
+	if code != status {
+		s.t.Fatalf("%s %s: status %d, want %d: %v", method, path, code, status, out)
+	}
+	return out
+}
+
+func (s *testServer) createGame(creator ed25519.PublicKey) int {
+	s.t.Helper()
+	return s.createGameOfType(creator, "")
+}
+
+// createGameOfType creates a game of the card format of gameType.
+func (s *testServer) createGameOfType(creator ed25519.PublicKey, gameType string) int {
+	s.t.Helper()
+	out := s.expect(http.StatusOK, http.MethodPost, "/game", CreateGameRequest{
+		Name:                 "Friday bingo",
+		Type:                 gameType,
+		StartTimestamp:       strconv.FormatInt(s.now.Unix()+60, 10),
+		CreatorWalletAddress: address(s.t, creator),
+		MintPrice:            "1 APT",
+		Interval:             testInterval,
+		CollectionUri:        "ipfs://collection",
+	})
+	id, err := strconv.Atoi(out["gameId"].(string))
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	return id
+}
+
+func (s *testServer) join(gameID int, player ed25519.PrivateKey) {
+	s.t.Helper()
+	pub := hexKey(player)
+	out := s.expect(http.StatusOK, http.MethodPost, "/game/join", JoinGameRequest{GameId: gameID, PublicKey: pub})
+	sig := signHex(s.t, player, out["signingMessage"].(string))
+	s.expect(http.StatusOK, http.MethodPost, "/game/join/submit", SubmitJoinRequest{
+		JoinId:    uint(out["joinId"].(float64)),
+		PublicKey: pub,
+		Signature: sig,
+	})
+}
+
+func (s *testServer) start(gameID int) {
+	s.t.Helper()
+	s.now = s.now.Add(time.Minute)
+	s.expect(http.StatusOK, http.MethodPost, "/game/start", StartGameRequest{GameId: gameID})
+}
+
+func (s *testServer) draw(gameID int) map[string]interface{} {
+	s.t.Helper()
+	s.now = s.now.Add(testInterval * time.Second)
+	return s.expect(http.StatusOK, http.MethodGet, "/game/drawNumber?gameId="+strconv.Itoa(gameID), nil)
+}
+
+func testKey(seed byte) ed25519.PrivateKey {
+	return ed25519.NewKeyFromSeed(bytes.Repeat([]byte{seed}, ed25519.SeedSize))
+}
+
+func hexKey(key ed25519.PrivateKey) string {
+	return "0x" + hex.EncodeToString(key.Public().(ed25519.PublicKey))
+}
+
+func address(t *testing.T, pub ed25519.PublicKey) string {
+	addr, err := smartcontract.PlayerAddress(hex.EncodeToString(pub))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return addr
+}
+
+func signHex(t *testing.T, key ed25519.PrivateKey, message string) string {
+	msg, err := hex.DecodeString(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hex.EncodeToString(ed25519.Sign(key, msg))
+}
+
+func TestGameFlow(t *testing.T) {
+	s := newTestServer(t)
+	creator, player := testKey(1), testKey(2)
+	playerAddress := address(t, player.Public().(ed25519.PublicKey))
+	s.chain.Fund(playerAddress, 10*100000000)
+
+	gameID := s.createGame(creator.Public().(ed25519.PublicKey))
+	s.join(gameID, player)
+
+	var ticket models.Ticket
+	if err := dbconfig.GetDb().Where("game_id = ? AND wallet_address = ?", gameID, playerAddress).First(&ticket).Error; err != nil {
+		t.Fatalf("ticket not saved: %s", err)
+	}
+	state, _ := s.chain.BingoGame(gameID)
+	if len(state.CardAddresses) != 1 {
+		t.Fatalf("got %d cards, want 1", len(state.CardAddresses))
+	}
+	card := state.CardAddresses[0]
+	if ticket.CardAddress != card {
+		t.Errorf("card address of the ticket %q, want %q", ticket.CardAddress, card)
+	}
+	claim := ClaimPrizeRequest{GameId: gameID, PublicKey: hexKey(player), Prize: "topLine", CardAddress: card}
+
+	s.start(gameID)
+	out := s.expect(http.StatusUnprocessableEntity, http.MethodPost, "/game/claim", claim)
+	if out["code"] != "NOT_WINNING_TICKET" {
+		t.Errorf("claim before the draw: got %v", out)
+	}
+
+	// Draw until a line of the ticket is complete and claim it.
+	rows, err := utils.ParseFlatTicket(ticket.Ticket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	drawn := make(map[int]bool)
+	claim.Prize = ""
+	for claim.Prize == "" {
+		out := s.draw(gameID)
+		n, err := strconv.Atoi(out["number"].(string))
+		if err != nil {
+			t.Fatalf("draw: %v", out)
+		}
+		drawn[n] = true
+		for i, prize := range []string{"topLine", "middleLine", "bottomLine"} {
+			if claim.Prize == "" && utils.IsRowDrawn(rows[i], drawn) {
+				claim.Prize = prize
+			}
+		}
+	}
+
+	out = s.expect(http.StatusOK, http.MethodPost, "/game/claim", claim)
+	submit := SubmitClaimRequest{
+		GameId:    gameID,
+		PublicKey: hexKey(player),
+		Prize:     claim.Prize,
+		Signature: signHex(t, player, out["signingMessage"].(string)),
+	}
+	s.expect(http.StatusOK, http.MethodPost, "/game/claim/submit", submit)
+	// The claim is sent once.
+	s.expect(http.StatusNotFound, http.MethodPost, "/game/claim/submit", submit)
+	state, _ = s.chain.BingoGame(gameID)
+	if state.ClaimPending.Pendings != 1 {
+		t.Errorf("got %d pending claims, want 1", state.ClaimPending.Pendings)
+	}
+	if err := dbconfig.GetDb().Where("game_id = ? AND wallet_address = ?", gameID, playerAddress).First(&ticket).Error; err != nil || ticket.CardAddress != card {
+		t.Errorf("card address of the ticket %q, want %q", ticket.CardAddress, card)
+	}
+
+	// The next draw pays the claim.
+	s.draw(gameID)
+	if balance := s.chain.Balances[playerAddress]; balance <= 9*100000000 {
+		t.Errorf("player balance %d after the prize", balance)
+	}
+}
+
+// TestFormatFlow plays a 75-ball and a 30-ball game to a full house: the card
+// is joined as its ChainRows and claimed once they are drawn.
+func TestFormatFlow(t *testing.T) {
+	for _, format := range []utils.CardFormat{utils.SeventyFiveBall, utils.ThirtyBall} {
+		s := newTestServer(t)
+		player := testKey(2)
+		playerAddress := address(t, player.Public().(ed25519.PublicKey))
+		s.chain.Fund(playerAddress, 10*100000000)
+		gameID := s.createGameOfType(testKey(1).Public().(ed25519.PublicKey), format.Name())
+		s.join(gameID, player)
+
+		var ticket models.Ticket
+		if err := dbconfig.GetDb().Where("game_id = ? AND wallet_address = ?", gameID, playerAddress).First(&ticket).Error; err != nil {
+			t.Fatalf("%s: ticket not saved: %s", format.Name(), err)
+		}
+		card, err := utils.ParseCard(format, ticket.Ticket)
+		if err != nil {
+			t.Fatalf("%s: %v", format.Name(), err)
+		}
+		rows := format.ChainRows(card)
+		state, _ := s.chain.BingoGame(gameID)
+		if len(state.Cards) != 1 || !reflect.DeepEqual(utils.Card(state.Cards[0]), rows) {
+			t.Fatalf("%s: joined %v, want %v", format.Name(), state.Cards, rows)
+		}
+
+		s.start(gameID)
+		drawn := make(map[int]bool)
+		for !utils.LineDrawn(rows[0], drawn) || !utils.LineDrawn(rows[1], drawn) || !utils.LineDrawn(rows[2], drawn) {
+			out := s.draw(gameID)
+			n, err := strconv.Atoi(out["number"].(string))
+			if err != nil {
+				t.Fatalf("%s: draw: %v", format.Name(), out)
+			}
+			drawn[n] = true
+		}
+		claim := ClaimPrizeRequest{GameId: gameID, PublicKey: hexKey(player), Prize: "fullHouse", CardAddress: ticket.CardAddress}
+		out := s.expect(http.StatusOK, http.MethodPost, "/game/claim", claim)
+		s.expect(http.StatusOK, http.MethodPost, "/game/claim/submit", SubmitClaimRequest{
+			GameId:    gameID,
+			PublicKey: hexKey(player),
+			Prize:     claim.Prize,
+			Signature: signHex(t, player, out["signingMessage"].(string)),
+		})
+		if state, _ = s.chain.BingoGame(gameID); state.ClaimPending.Pendings != 1 {
+			t.Errorf("%s: got %d pending claims, want 1", format.Name(), state.ClaimPending.Pendings)
+		}
+	}
+}
+
+func TestClaimOtherCard(t *testing.T) {
+	s := newTestServer(t)
+	alice, bob := testKey(2), testKey(3)
+	for _, player := range []ed25519.PrivateKey{alice, bob} {
+		s.chain.Fund(address(t, player.Public().(ed25519.PublicKey)), 100000000)
+	}
+	gameID := s.createGame(testKey(1).Public().(ed25519.PublicKey))
+	s.join(gameID, alice)
+	s.join(gameID, bob)
+	s.start(gameID)
+
+	state, _ := s.chain.BingoGame(gameID)
+	claim := ClaimPrizeRequest{GameId: gameID, PublicKey: hexKey(alice), Prize: "topLine", CardAddress: state.CardAddresses[1]}
+	out := s.expect(http.StatusBadRequest, http.MethodPost, "/game/claim", claim)
+	if out["code"] != "CARD_MISMATCH" {
+		t.Errorf("got %v", out)
+	}
+
+	// Without a card saved with the ticket, the owner is read from the chain.
+	if err := dbconfig.GetDb().Model(&models.Ticket{}).
+		Where("game_id = ? AND wallet_address = ?", gameID, address(t, alice.Public().(ed25519.PublicKey))).
+		Update("card_address", "").Error; err != nil {
+		t.Fatal(err)
+	}
+	out = s.expect(http.StatusForbidden, http.MethodPost, "/game/claim", claim)
+	if out["code"] != "CARD_MISMATCH" {
+		t.Errorf("got %v", out)
+	}
+}
+
+func TestCancelGame(t *testing.T) {
+	s := newTestServer(t)
+	creator, other := testKey(1), testKey(2)
+	gameID := s.createGame(creator.Public().(ed25519.PublicKey))
+	message := smartcontract.CancelMessage(s.chain.BingoModule(), gameID)
+	cancel := func(key ed25519.PrivateKey, message string) CancelGameRequest {
+		return CancelGameRequest{
+			GameId:    gameID,
+			PublicKey: hexKey(key),
+			Signature: hex.EncodeToString(ed25519.Sign(key, []byte(message))),
+		}
+	}
+
+	out := s.expect(http.StatusForbidden, http.MethodPost, "/game/cancel", cancel(other, message))
+	if out["code"] != "NOT_GAME_CREATOR" {
+		t.Errorf("cancel by another wallet: got %v", out)
+	}
+	out = s.expect(http.StatusBadRequest, http.MethodPost, "/game/cancel", cancel(creator, "Cancel game 99"))
+	if out["code"] != "INVALID_SIGNATURE" {
+		t.Errorf("cancel with another message: got %v", out)
+	}
+	s.expect(http.StatusOK, http.MethodPost, "/game/cancel", cancel(creator, message))
+
+	var game models.Game
+	if err := dbconfig.GetDb().Where("game_id = ?", gameID).First(&game).Error; err != nil {
+		t.Fatal(err)
+	}
+	if game.Status != models.GameStatusCancelled {
+		t.Errorf("game status %q, want %q", game.Status, models.GameStatusCancelled)
+	}
+	if state, _ := s.chain.BingoGame(gameID); !state.IsFinished {
+		t.Error("game not cancelled on chain")
+	}
+}
+
+// TestAbortStatus checks that the aborts of the contract reach the client
+// with their HTTP status and code.
+func TestAbortStatus(t *testing.T) {
+	s := newTestServer(t)
+	creator, player := testKey(1), testKey(2)
+	gameID := s.createGame(creator.Public().(ed25519.PublicKey))
+	id := strconv.Itoa(gameID)
+	cancel := CancelGameRequest{
+		GameId:    gameID,
+		PublicKey: hexKey(creator),
+		Signature: hex.EncodeToString(ed25519.Sign(creator, []byte(smartcontract.CancelMessage(s.chain.BingoModule(), gameID)))),
+	}
+
+	// The player has no coins to buy the card with.
+	out := s.expect(http.StatusOK, http.MethodPost, "/game/join", JoinGameRequest{GameId: gameID, PublicKey: hexKey(player)})
+	join := SubmitJoinRequest{JoinId: uint(out["joinId"].(float64)), PublicKey: hexKey(player), Signature: "00"}
+
+	tests := []struct {
+		name   string
+		before func()
+		method string
+		path   string
+		body   interface{}
+		status int
+		code   string
+	}{
+		{"create in the past", nil, http.MethodPost, "/game", CreateGameRequest{
+			Name: "Late", StartTimestamp: strconv.FormatInt(s.now.Unix()-60, 10), MintPrice: "1", Interval: 1, CollectionUri: "ipfs://c",
+		}, http.StatusBadRequest, "INVALID_START_TIMESTAMP"},
+		{"start too early", nil, http.MethodPost, "/game/start", StartGameRequest{GameId: gameID}, http.StatusConflict, "CANT_START_GAME_YET"},
+		{"draw before the start", nil, http.MethodGet, "/game/drawNumber?gameId=" + id, nil, http.StatusConflict, "GAME_NOT_STARTED"},
+		{"join without coins", nil, http.MethodPost, "/game/join/submit", join, http.StatusPaymentRequired, "INSUFFICIENT_BALANCE"},
+		{"start twice", func() { s.start(gameID) }, http.MethodPost, "/game/start", StartGameRequest{GameId: gameID}, http.StatusConflict, "GAME_HAS_STARTED"},
+		{"draw too soon", func() { s.draw(gameID) }, http.MethodGet, "/game/drawNumber?gameId=" + id, nil, http.StatusTooManyRequests, "NEED_TO_WAIT_INTERVAL_TIME"},
+		{"join once started", nil, http.MethodPost, "/game/join", JoinGameRequest{GameId: gameID, PublicKey: hexKey(testKey(3))}, http.StatusConflict, "GAME_HAS_STARTED"},
+		{"cancel once started", nil, http.MethodPost, "/game/cancel", cancel, http.StatusConflict, "GAME_HAS_STARTED"},
+	}
+	for _, tt := range tests {
+		if tt.before != nil {
+			tt.before()
+		}
+		code, out := s.do(tt.method, tt.path, tt.body)
+		if code != tt.status || out["code"] != tt.code {
+			t.Errorf("%s: got %d %v, want %d %s", tt.name, code, out, tt.status, tt.code)
+		}
+	}
+}
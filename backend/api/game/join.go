@@ -0,0 +1,223 @@
+package game
+
+import (
+	"VirtueGaming/api/apierror"
+	"VirtueGaming/config/dbconfig"
+	"VirtueGaming/models"
+	"VirtueGaming/utils"
+	"VirtueGaming/utils/smartcontract"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Steps of the join flow, reported back to the client when one fails.
+const (
+	stepIssueTicket = "issue_ticket"
+	stepRender      = "render_ticket"
+	stepPinImage    = "pin_image"
+	stepPinMetadata = "pin_metadata"
+	stepJoinGame    = "join_game"
+	stepReserveJoin = "reserve_join"
+	stepSubmitJoin  = "submit_join"
+	stepSaveTicket  = "save_ticket"
+)
+
+// JoinGame issues the ticket of the player, in the card format of the game and
+// from the seed of the game, renders and pins it and returns the join_game
+// transaction for the player to sign. SubmitJoin sends it once signed, the
+// card is bought by the player's account.
+func (h *handler) JoinGame(c *gin.Context) {
+	var req JoinGameRequest
+	if err := c.BindJSON(&req); err != nil {
+		logrus.Error("failed to bind request: ", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	game, module, err := h.findGame(strconv.Itoa(req.GameId), req.Module)
+	if err != nil {
+		logrus.Error("failed to fetch game: ", err)
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	format := utils.FormatForType(game.Type)
+
+	// The ticket is seeded by the wallet that buys it, the account of the
+	// player's key.
+	wallet, err := smartcontract.PlayerAddress(req.PublicKey)
+	if err != nil {
+		apierror.RespondStep(c, stepJoinGame, err)
+		return
+	}
+	issued, card, err := h.registry.Issue(format, module.ID(), game.GameId, wallet)
+	if err != nil {
+		apierror.RespondStep(c, stepIssueTicket, err)
+		return
+	}
+	if err := format.Validate(card); err != nil {
+		apierror.RespondStep(c, stepRender, err)
+		return
+	}
+	flatTicket := card.Flatten()
+
+	imgbytes, err := h.renderTicket(format.Cells(card))
+	if err != nil {
+		apierror.RespondStep(c, stepRender, err)
+		return
+	}
+	imageIPFSHash, err := h.pinImage(imgbytes)
+	if err != nil {
+		apierror.RespondStep(c, stepPinImage, err)
+		return
+	}
+	metadataBytes, err := json.Marshal(models.Metadata{
+		GameId:      game.GameId,
+		Type:        game.Type,
+		Name:        game.Name,
+		Description: game.Description,
+		Format:      format.Name(),
+		Ticket:      flatTicket,
+		Nonce:       issued.Nonce,
+		Image:       "ipfs://" + imageIPFSHash + "/image.jpeg",
+	})
+	if err != nil {
+		apierror.RespondStep(c, stepPinMetadata, err)
+		return
+	}
+	metadataHash, err := h.pinMetadata(metadataBytes)
+	if err != nil {
+		apierror.RespondStep(c, stepPinMetadata, err)
+		return
+	}
+	metadataUri := "ipfs://" + metadataHash
+
+	params := smartcontract.JoinGameParams{
+		Module:          module,
+		GameID:          game.GameId,
+		Uri:             metadataUri,
+		Ticket:          format.ChainRows(card),
+		PlayerPublicKey: req.PublicKey,
+	}
+	tx, err := h.chain.PrepareJoinGame(params, req.Sponsored)
+	if err != nil {
+		apierror.RespondStep(c, stepJoinGame, err)
+		return
+	}
+	record := models.Sponsorship{
+		Module:      module.ID(),
+		GameId:      game.GameId,
+		Ticket:      flatTicket,
+		MetadataUri: metadataUri,
+	}
+	if err := h.sponsor.Reserve(&record, tx); err != nil {
+		apierror.RespondStep(c, stepReserveJoin, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"joinId":         record.ID,
+		"sender":         tx.Sender,
+		"feePayer":       tx.FeePayer,
+		"rawTransaction": tx.RawTransaction,
+		"signingMessage": tx.SigningMessage,
+		"expiresAt":      tx.ExpiresAt,
+		"ticket":         flatTicket,
+		"metadata":       metadataUri,
+	})
+}
+
+// SubmitJoin sends a join once the player signed it, and stores the card.
+func (h *handler) SubmitJoin(c *gin.Context) {
+	var req SubmitJoinRequest
+	if err := c.BindJSON(&req); err != nil {
+		logrus.Error("failed to bind request: ", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	record, err := h.sponsor.Find(req.JoinId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		apierror.RespondStep(c, stepSubmitJoin, err)
+		return
+	}
+	game, module, err := h.findGame(strconv.Itoa(record.GameId), record.Module)
+	if err != nil {
+		apierror.RespondStep(c, stepSubmitJoin, err)
+		return
+	}
+	format := utils.FormatForType(game.Type)
+	card, err := utils.ParseCard(format, record.Ticket)
+	if err != nil {
+		apierror.RespondStep(c, stepSubmitJoin, err)
+		return
+	}
+	params := smartcontract.JoinGameParams{
+		Module:          module,
+		GameID:          record.GameId,
+		Uri:             record.MetadataUri,
+		Ticket:          format.ChainRows(card),
+		PlayerPublicKey: req.PublicKey,
+	}
+	if err := h.sponsor.Submitted(record); err != nil {
+		apierror.RespondStep(c, stepSubmitJoin, err)
+		return
+	}
+	tx, err := h.chain.SubmitSigned(smartcontract.NewJoinTx(params, record.WalletAddress, record.FeePayer, record.RawTransaction),
+		req.PublicKey, req.Signature)
+	if tx != nil {
+		if err := h.sponsor.Settle(record, tx); err != nil {
+			logrus.Errorf("failed to settle join %d: %s", record.ID, err)
+		}
+	}
+	// Only a transaction the node never accepted gives its reservation back,
+	// one that timed out waiting may still be committed.
+	if errors.Is(err, smartcontract.ErrInvalidPublicKey) || errors.Is(err, smartcontract.ErrInvalidSignature) ||
+		errors.Is(err, smartcontract.ErrNotSubmitted) {
+		if err := h.sponsor.Release(record); err != nil {
+			logrus.Errorf("failed to release join %d: %s", record.ID, err)
+		}
+	}
+	if err != nil {
+		apierror.RespondStep(c, stepJoinGame, err)
+		return
+	}
+	saveTicket(c, params, record.WalletAddress, record.Ticket, tx)
+}
+
+// saveTicket stores the card bought in tx and answers the join request.
+func saveTicket(c *gin.Context, p smartcontract.JoinGameParams, walletAddress, flatTicket string, tx *smartcontract.TxResult) {
+	txHash := tx.Result.TransactionHash
+	cardAddress, ok := tx.TransferredObject(walletAddress)
+	if !ok {
+		logrus.Errorf("no card transferred to %s in %s", walletAddress, txHash)
+	}
+	record := models.Ticket{
+		GameId:          p.GameID,
+		Module:          p.Module.ID(),
+		WalletAddress:   walletAddress,
+		Ticket:          flatTicket,
+		MetadataUri:     p.Uri,
+		TransactionHash: txHash,
+		CardAddress:     cardAddress,
+	}
+	db := dbconfig.GetDb()
+	if err := db.Model(&models.Ticket{}).Create(&record).Error; err != nil {
+		apierror.RespondStep(c, stepSaveTicket, fmt.Errorf("ticket bought in %s but not saved: %w", txHash, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":        txHash,
+		"ticket":      flatTicket,
+		"metadata":    p.Uri,
+		"cardAddress": cardAddress,
+	})
+}
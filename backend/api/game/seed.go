@@ -0,0 +1,76 @@
+package game
+
+import (
+	"VirtueGaming/models"
+	"VirtueGaming/utils"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+var errSeedNotRevealed = errors.New("the game secret is revealed once the game is over")
+
+// gameOver reports whether the secret of the game may be published: no
+// ticket is sold anymore.
+func gameOver(game *models.Game) bool {
+	return game.Status == models.GameStatusFinished || game.Status == models.GameStatusCancelled
+}
+
+// GetSeed returns the commitment to the secret the tickets of a game are
+// seeded with and, once the game is over, the secret itself.
+func (h *handler) GetSeed(c *gin.Context) {
+	game, module, err := h.findGame(c.Query("gameId"), c.Query("module"))
+	if err != nil {
+		logrus.Error("failed to fetch game: ", err)
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	secret := h.seeder.GameSecret(module.ID(), game.GameId)
+	res := gin.H{
+		"gameId":     game.GameId,
+		"module":     module.ID(),
+		"commitment": utils.SeedCommitment(secret),
+	}
+	if gameOver(game) {
+		res["secret"] = hex.EncodeToString(secret)
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// VerifyTicket recomputes the ticket of a wallet from the revealed secret of
+// a game, the way any player can with utils.VerifyTicket.
+func (h *handler) VerifyTicket(c *gin.Context) {
+	var req VerifyTicketRequest
+	if err := c.BindJSON(&req); err != nil {
+		logrus.Error("failed to bind request: ", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	game, module, err := h.findGame(strconv.Itoa(req.GameId), req.Module)
+	if err != nil {
+		logrus.Error("failed to fetch game: ", err)
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	format := utils.FormatForType(game.Type)
+	card, err := utils.ParseCard(format, req.Ticket)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !gameOver(game) {
+		c.JSON(http.StatusConflict, gin.H{"error": errSeedNotRevealed.Error(), "code": "SEED_NOT_REVEALED"})
+		return
+	}
+	secret := h.seeder.GameSecret(module.ID(), game.GameId)
+	err = utils.VerifyTicket(format, hex.EncodeToString(secret), utils.SeedCommitment(secret), module.ID(), game.GameId, req.WalletAddress, req.Nonce, card)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"valid": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"valid": true})
+}
@@ -9,41 +9,85 @@ type CreateGameRequest struct {
 	Description          string `json:"description"`
 	CreatorWalletAddress string `json:"creatorWalletAddress"`
 	Type                 string `json:"type"`
+	// MintPrice is the price of a card, in APT ("1.5 APT") or in octas
+	// ("150000000").
+	MintPrice string `json:"mintPrice"`
+	// Interval is the minimum number of seconds between two draws.
+	Interval uint64 `json:"interval"`
+	// The collection of the cards defaults to the name and description of
+	// the game, and to collection metadata pinned from the game details.
+	CollectionName        string `json:"collectionName"`
+	CollectionDescription string `json:"collectionDescription"`
+	CollectionUri         string `json:"collectionUri"`
+	// RoyaltyNumerator is the royalty on card sales in percent.
+	RoyaltyNumerator uint64 `json:"royaltyNumerator"`
 }
 
-type GetGameReqest struct {
-	GameId int `json:"gameId"`
+type StartGameRequest struct {
+	GameId int    `json:"gameId"`
+	Module string `json:"module"`
+}
+
+// CancelGameRequest carries the signature of the creator of the game of
+// smartcontract.CancelMessage, made with the key of PublicKey. FullMessage is
+// the message the wallet actually signed, when it wrapped it.
+type CancelGameRequest struct {
+	GameId      int    `json:"gameId"`
+	Module      string `json:"module"`
+	PublicKey   string `json:"publicKey"`
+	Signature   string `json:"signature"`
+	FullMessage string `json:"fullMessage"`
 }
 
-type Data struct {
-	GameID         string `json:"game_id"`
-	GameName       string `json:"game_name"`
-	Timestamp      string `json:"timestamp"`
-	StartTimestamp string `json:"start_timestamp"`
+// JoinGameRequest prepares the join of the player of PublicKey, the hex
+// encoded key of their wallet. Sponsored has the backend pay the gas.
+type JoinGameRequest struct {
+	GameId    int    `json:"gameId"`
+	Module    string `json:"module"`
+	PublicKey string `json:"publicKey"`
+	Sponsored bool   `json:"sponsored"`
 }
 
-type Event struct {
-	Data Data `json:"data"`
+// SubmitJoinRequest carries the player's signature of the transaction
+// JoinGame returned.
+type SubmitJoinRequest struct {
+	JoinId    uint   `json:"joinId"`
+	PublicKey string `json:"publicKey"`
+	Signature string `json:"signature"`
 }
 
-type Response struct {
-	Data struct {
-		Events []Event `json:"events"`
-	} `json:"data"`
+// ClaimPrizeRequest prepares the claim of the player of PublicKey with the
+// card at CardAddress, which the player must own.
+type ClaimPrizeRequest struct {
+	GameId    int    `json:"gameId"`
+	Module    string `json:"module"`
+	PublicKey string `json:"publicKey"`
+	// Prize is one of topLine, middleLine, bottomLine or fullHouse.
+	Prize       string `json:"prize"`
+	CardAddress string `json:"cardAddress"`
 }
 
-type DrawNumberData struct {
-	GameID    string `json:"game_id"`
-	Number    string `json:"number"`
-	Timestamp string `json:"timestamp"`
+// SubmitClaimRequest carries the player's signature of the transaction
+// ClaimPrize returned for the same game and prize.
+type SubmitClaimRequest struct {
+	GameId    int    `json:"gameId"`
+	Module    string `json:"module"`
+	PublicKey string `json:"publicKey"`
+	Prize     string `json:"prize"`
+	Signature string `json:"signature"`
 }
 
-type DrawNumberEvent struct {
-	Data DrawNumberData `json:"data"`
+// VerifyTicketRequest checks the ticket of a wallet, flattened like the
+// tickets of JoinGame, against the seed of a game that is over.
+type VerifyTicketRequest struct {
+	GameId        int    `json:"gameId"`
+	Module        string `json:"module"`
+	WalletAddress string `json:"walletAddress"`
+	Ticket        string `json:"ticket"`
+	// Nonce is the nonce in the metadata of the ticket.
+	Nonce int `json:"nonce"`
 }
 
-type DrawNumberResponse struct {
-	Data struct {
-		Events []DrawNumberEvent `json:"events"`
-	} `json:"data"`
+type GetGameReqest struct {
+	GameId int `json:"gameId"`
 }
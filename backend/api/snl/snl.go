@@ -1,22 +1,59 @@
 package snl
 
 import (
+	"VirtueGaming/api/apierror"
 	"VirtueGaming/config/dbconfig"
 	"VirtueGaming/models"
-	"math/rand"
+	"VirtueGaming/utils"
+	"VirtueGaming/utils/aptos"
+	"VirtueGaming/utils/indexer"
+	"VirtueGaming/utils/smartcontract"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"os"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 )
 
-func ApplyRoutes(r *gin.RouterGroup) {
+// handler holds the dependencies of the routes that talk to the chain.
+type handler struct {
+	chain   smartcontract.ChainClient
+	indexer *indexer.Client
+
+	// pinMetadata pins a file to IPFS and returns its hash.
+	pinMetadata func(data []byte) (string, error)
+}
+
+func ApplyRoutes(r *gin.RouterGroup, chain smartcontract.ChainClient, idx *indexer.Client) {
+	h := &handler{
+		chain:   chain,
+		indexer: idx,
+		pinMetadata: func(data []byte) (string, error) {
+			return utils.UploadMetadataToNFTStorage(os.Getenv("NFT_STORAGE_KEY"), data)
+		},
+	}
+	h.routes(r)
+}
+
+func (h *handler) routes(r *gin.RouterGroup) {
 	g := r.Group("/snl")
 	{
-		g.POST("", CreateGame)
+		g.POST("", h.CreateGame)
 		g.GET("/all", GetAllGames)
 		g.GET("", GetGameById)
+		g.GET("/stats", h.GetStats)
+		g.POST("/join", h.JoinGame)
+		g.POST("/join/submit", h.SubmitJoin)
+		g.POST("/start", h.StartGame)
+		g.POST("/rollDice", h.RollDice)
+		g.POST("/rollDice/submit", h.SubmitRoll)
+		g.GET("/rolls", h.GetRolls)
+		g.POST("/gameWon", h.GameWon)
+		g.POST("/cancel", h.CancelGame)
 	}
 }
 
@@ -54,7 +91,43 @@ func GetGameById(c *gin.Context) {
 	c.JSON(http.StatusOK, game)
 
 }
-func CreateGame(c *gin.Context) {
+
+// module parses the module a request targets, the configured one when
+// moduleId is empty.
+func (h *handler) module(moduleId string) (smartcontract.Module, error) {
+	if moduleId == "" {
+		return h.chain.SnlModule(), nil
+	}
+	return smartcontract.ParseModule(moduleId)
+}
+
+// findGame loads a snakes and ladders game by its on-chain id, see the bingo
+// counterpart in api/game.
+func (h *handler) findGame(gameId int, moduleId string) (*models.SnlGame, smartcontract.Module, error) {
+	module, err := h.module(moduleId)
+	if err != nil {
+		return nil, module, err
+	}
+	var game models.SnlGame
+	db := dbconfig.GetDb()
+	if err := db.Model(&models.SnlGame{}).Where("game_id = ? AND (module = ? OR module = '')", gameId, module.ID()).First(&game).Error; err != nil {
+		return nil, module, err
+	}
+	return &game, module, nil
+}
+
+func updateGameStatus(game *models.SnlGame, status string) error {
+	db := dbconfig.GetDb()
+	return db.Model(&models.SnlGame{}).
+		Where("game_id = ? AND module = ?", game.GameId, game.Module).
+		Update("status", status).Error
+}
+
+// stepPinCollection is the step of creating a game that pins the collection
+// metadata.
+const stepPinCollection = "pin_collection_metadata"
+
+func (h *handler) CreateGame(c *gin.Context) {
 	//create game
 	var req CreateGameRequest
 	err := c.BindJSON(&req)
@@ -63,26 +136,634 @@ func CreateGame(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	// Generate a random 6-digit number
-	gameIdInt := rand.Intn(900000) + 100000
+	params, err := createGameParams(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": "INVALID_GAME_PARAMS"})
+		return
+	}
+	if params.CollectionURI == "" {
+		uri, err := h.pinCollectionMetadata(req)
+		if err != nil {
+			apierror.RespondStep(c, stepPinCollection, err)
+			return
+		}
+		params.CollectionURI = uri
+	}
+	module := h.chain.SnlModule()
+	tx, err := h.chain.SnlCreateGame(params)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+	txHash := tx.Result.TransactionHash
+
+	var event indexer.CreateGameEvent
+	if err := h.indexer.TxEvent(c.Request.Context(), tx, module.EventType("CreateGameEvent"), &event); err != nil {
+		logrus.Errorf("failed to read game id of %s: %s", txHash, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error(), "data": txHash})
+		return
+	}
+	gameIdInt := event.GameID
+	gameId := strconv.Itoa(gameIdInt)
 	game := models.SnlGame{
-		Name:                 req.Name,
-		Symbol:               req.Symbol,
-		Picture:              req.Picture,
-		CoverImage:           req.CoverImage,
-		Description:          req.Description,
-		CreatorWalletAddress: req.CreatorWalletAddress,
-		Type:                 req.Type,
-		//TransactionHash:      txHash,
-		GameId: gameIdInt,
+		Name:                  req.Name,
+		StartTimestamp:        req.StartTimestamp,
+		Symbol:                req.Symbol,
+		Picture:               req.Picture,
+		CoverImage:            req.CoverImage,
+		Description:           req.Description,
+		CreatorWalletAddress:  req.CreatorWalletAddress,
+		Type:                  req.Type,
+		TransactionHash:       txHash,
+		MintPrice:             int64(params.MintPrice),
+		Interval:              int64(params.Interval),
+		CollectionName:        params.CollectionName,
+		CollectionDescription: params.CollectionDescription,
+		CollectionUri:         params.CollectionURI,
+		RoyaltyNumerator:      int64(params.RoyaltyNumerator),
+	}
+	// The ingester may have stored the game from its CreateGameEvent already.
+	db := dbconfig.GetDb()
+	if err = db.Model(&models.SnlGame{}).
+		Where(map[string]interface{}{"game_id": gameIdInt, "module": module.ID()}).
+		Attrs(models.SnlGame{Status: models.GameStatusNotStarted}).
+		Assign(game).
+		FirstOrCreate(&game).Error; err != nil {
+		logrus.Error("db err: ", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": txHash, "gameId": gameId})
+}
+
+// createGameParams converts and validates the create_game arguments of req,
+// see the bingo counterpart in api/game.
+func createGameParams(req CreateGameRequest) (smartcontract.CreateGameParams, error) {
+	p := smartcontract.CreateGameParams{
+		GameName:              req.Name,
+		StartTimestamp:        req.StartTimestamp,
+		Interval:              req.Interval,
+		CollectionName:        req.CollectionName,
+		CollectionDescription: req.CollectionDescription,
+		CollectionURI:         req.CollectionUri,
+		RoyaltyNumerator:      req.RoyaltyNumerator,
+	}
+	if p.CollectionName == "" {
+		p.CollectionName = req.Name
+	}
+	if p.CollectionDescription == "" {
+		p.CollectionDescription = req.Description
+	}
+	if req.MintPrice == "" {
+		return p, fmt.Errorf("mint price is required")
+	}
+	var err error
+	if p.MintPrice, err = aptos.ParseAmount(req.MintPrice); err != nil {
+		return p, err
+	}
+	return p, p.Validate()
+}
+
+// pinCollectionMetadata pins the metadata of the avatar collection of a game
+// and returns its ipfs:// URI.
+func (h *handler) pinCollectionMetadata(req CreateGameRequest) (string, error) {
+	metadata, err := json.Marshal(models.CollectionMetadata{
+		Name:        req.Name,
+		Symbol:      req.Symbol,
+		Description: req.Description,
+		Image:       req.Picture,
+		CoverImage:  req.CoverImage,
+	})
+	if err != nil {
+		return "", err
+	}
+	hash, err := h.pinMetadata(metadata)
+	if err != nil {
+		return "", err
+	}
+	return "ipfs://" + hash, nil
+}
+
+// JoinGame pins the avatar metadata and returns the join_game transaction for
+// the player to sign. SubmitJoin sends it, the avatar is bought by the
+// player's account.
+func (h *handler) JoinGame(c *gin.Context) {
+	var req JoinGameRequest
+	if err := c.BindJSON(&req); err != nil {
+		logrus.Error("failed to bind request: ", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	game, module, err := h.findGame(req.GameId, req.Module)
+	if err != nil {
+		logrus.Error("failed to fetch game: ", err)
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	wallet, err := smartcontract.PlayerAddress(req.PublicKey)
+	if err != nil {
+		apierror.RespondStep(c, "join_game", err)
+		return
+	}
+	metadataBytes, err := json.Marshal(AvatarMetadata{
+		GameId:      game.GameId,
+		Type:        game.Type,
+		Name:        game.Name,
+		Description: game.Description,
+		Image:       game.Picture,
+	})
+	if err != nil {
+		apierror.RespondStep(c, "pin_metadata", err)
+		return
+	}
+	metadataHash, err := h.pinMetadata(metadataBytes)
+	if err != nil {
+		apierror.RespondStep(c, "pin_metadata", err)
+		return
+	}
+	metadataUri := "ipfs://" + metadataHash
+
+	tx, err := h.chain.PrepareSnlJoinGame(smartcontract.SnlJoinGameParams{
+		Module:          module,
+		GameID:          game.GameId,
+		Uri:             metadataUri,
+		PlayerPublicKey: req.PublicKey,
+	})
+	if err != nil {
+		apierror.RespondStep(c, "join_game", err)
+		return
+	}
+
+	// A join prepared again replaces the one the player did not sign.
+	player := models.SnlPlayer{
+		GameId:        game.GameId,
+		Module:        module.ID(),
+		WalletAddress: wallet,
+		Status:        models.SnlPlayerStatusPrepared,
+	}
+	db := dbconfig.GetDb()
+	if err := db.Model(&models.SnlPlayer{}).
+		Where(&player).
+		Assign(models.SnlPlayer{MetadataUri: metadataUri, RawTransaction: tx.RawTransaction}).
+		FirstOrCreate(&player).Error; err != nil {
+		apierror.RespondStep(c, "save_player", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"sender":         tx.Sender,
+		"rawTransaction": tx.RawTransaction,
+		"signingMessage": tx.SigningMessage,
+		"expiresAt":      tx.ExpiresAt,
+		"metadata":       metadataUri,
+	})
+}
+
+// SubmitJoin sends a join once the player signed it, and stores the avatar
+// the transaction minted for the player.
+func (h *handler) SubmitJoin(c *gin.Context) {
+	var req SubmitJoinRequest
+	if err := c.BindJSON(&req); err != nil {
+		logrus.Error("failed to bind request: ", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	game, module, err := h.findGame(req.GameId, req.Module)
+	if err != nil {
+		logrus.Error("failed to fetch game: ", err)
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	wallet, err := smartcontract.PlayerAddress(req.PublicKey)
+	if err != nil {
+		apierror.RespondStep(c, "submit_join", err)
+		return
 	}
+
 	db := dbconfig.GetDb()
-	tx := db.Model(&models.SnlGame{}).Create(&game)
-	if tx.Error != nil {
+	prepared := models.SnlPlayer{
+		GameId:        game.GameId,
+		Module:        module.ID(),
+		WalletAddress: wallet,
+		Status:        models.SnlPlayerStatusPrepared,
+	}
+	var player models.SnlPlayer
+	if err := db.Model(&models.SnlPlayer{}).Where(&prepared).First(&player).Error; err != nil {
+		logrus.Error("failed to fetch player: ", err)
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	// Only one request may send a prepared join, it is cleared until the
+	// outcome is known.
+	res := db.Model(&models.SnlPlayer{}).
+		Where(&prepared).
+		Where("raw_transaction = ?", player.RawTransaction).
+		Update("raw_transaction", "")
+	if res.Error != nil {
+		apierror.RespondStep(c, "submit_join", res.Error)
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "join is not waiting for a signature", "code": "JOIN_NOT_PREPARED"})
+		return
+	}
+	submitted := prepared
+
+	params := smartcontract.SnlJoinGameParams{
+		Module:          module,
+		GameID:          game.GameId,
+		Uri:             player.MetadataUri,
+		PlayerPublicKey: req.PublicKey,
+	}
+	tx, err := h.chain.SubmitSigned(smartcontract.NewSnlJoinTx(params, wallet, player.RawTransaction), req.PublicKey, req.Signature)
+	if err != nil {
+		// A join the node never accepted can be signed again, one that
+		// failed on chain has to be prepared again.
+		switch {
+		case errors.Is(err, smartcontract.ErrInvalidPublicKey) || errors.Is(err, smartcontract.ErrInvalidSignature) ||
+			errors.Is(err, smartcontract.ErrNotSubmitted):
+			if err := db.Model(&models.SnlPlayer{}).Where(&submitted).Where("raw_transaction = ''").
+				Update("raw_transaction", player.RawTransaction).Error; err != nil {
+				logrus.Error("db err: ", err)
+			}
+		case tx != nil:
+			if err := db.Where(&submitted).Where("raw_transaction = ''").Delete(&models.SnlPlayer{}).Error; err != nil {
+				logrus.Error("db err: ", err)
+			}
+		}
+		apierror.RespondStep(c, "join_game", err)
+		return
+	}
+	txHash := tx.Result.TransactionHash
+
+	avatar, ok := tx.TransferredObject(wallet)
+	if !ok {
+		logrus.Errorf("no avatar transferred to %s in %s", wallet, txHash)
+	}
+	if err := db.Model(&models.SnlPlayer{}).Where(&submitted).Where("raw_transaction = ''").Updates(models.SnlPlayer{
+		AvatarAddress:   avatar,
+		TransactionHash: txHash,
+		Status:          models.SnlPlayerStatusJoined,
+	}).Error; err != nil {
+		apierror.RespondStep(c, "save_player", fmt.Errorf("avatar bought in %s but not saved: %w", txHash, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": txHash, "metadata": player.MetadataUri, "avatarAddress": avatar})
+}
+
+func (h *handler) StartGame(c *gin.Context) {
+	var req GameRequest
+	if err := c.BindJSON(&req); err != nil {
+		logrus.Error("failed to bind request: ", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	game, module, err := h.findGame(req.GameId, req.Module)
+	if err != nil {
+		logrus.Error("failed to fetch game: ", err)
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	tx, err := h.chain.SnlStartGame(smartcontract.StartGameParams{Module: module, GameID: game.GameId})
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+	txHash := tx.Result.TransactionHash
+	if err := updateGameStatus(game, models.GameStatusStarted); err != nil {
 		logrus.Error("db err: ", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": tx.Error.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "data": txHash})
 		return
 	}
+	c.JSON(http.StatusOK, gin.H{"data": txHash})
+}
 
-	c.JSON(http.StatusOK, gin.H{"gameId": strconv.Itoa(gameIdInt)})
+// RollDice returns the roll_dice transaction of the avatar of the player for
+// them to sign, roll_dice only rolls for the avatars of its sender.
+// SubmitRoll sends it.
+func (h *handler) RollDice(c *gin.Context) {
+	var req RollDiceRequest
+	if err := c.BindJSON(&req); err != nil {
+		logrus.Error("failed to bind request: ", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	game, module, err := h.findGame(req.GameId, req.Module)
+	if err != nil {
+		logrus.Error("failed to fetch game: ", err)
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	wallet, err := smartcontract.PlayerAddress(req.PublicKey)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+	db := dbconfig.GetDb()
+	joined := models.SnlPlayer{
+		GameId:        game.GameId,
+		Module:        module.ID(),
+		WalletAddress: wallet,
+		Status:        models.SnlPlayerStatusJoined,
+	}
+	var player models.SnlPlayer
+	if err := db.Model(&models.SnlPlayer{}).Where(&joined).First(&player).Error; err != nil {
+		logrus.Error("failed to fetch player: ", err)
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if player.AvatarAddress == "" {
+		c.JSON(http.StatusConflict, gin.H{"error": "the avatar of the player is not known", "code": "AVATAR_NOT_FOUND"})
+		return
+	}
+	tx, err := h.chain.PrepareSnlRollDice(smartcontract.SnlRollDiceParams{
+		Module:          module,
+		GameID:          game.GameId,
+		AvatarAddress:   player.AvatarAddress,
+		PlayerPublicKey: req.PublicKey,
+	})
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+	// A roll prepared again replaces the one the player did not sign.
+	if err := db.Model(&models.SnlPlayer{}).Where(&joined).Update("roll_transaction", tx.RawTransaction).Error; err != nil {
+		logrus.Error("db err: ", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"sender":         tx.Sender,
+		"avatarAddress":  player.AvatarAddress,
+		"rawTransaction": tx.RawTransaction,
+		"signingMessage": tx.SigningMessage,
+		"expiresAt":      tx.ExpiresAt,
+	})
+}
+
+// SubmitRoll sends a roll once the player signed it and returns the number
+// rolled.
+func (h *handler) SubmitRoll(c *gin.Context) {
+	var req SubmitRollRequest
+	if err := c.BindJSON(&req); err != nil {
+		logrus.Error("failed to bind request: ", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	game, module, err := h.findGame(req.GameId, req.Module)
+	if err != nil {
+		logrus.Error("failed to fetch game: ", err)
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	wallet, err := smartcontract.PlayerAddress(req.PublicKey)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+	db := dbconfig.GetDb()
+	joined := models.SnlPlayer{
+		GameId:        game.GameId,
+		Module:        module.ID(),
+		WalletAddress: wallet,
+		Status:        models.SnlPlayerStatusJoined,
+	}
+	var player models.SnlPlayer
+	if err := db.Model(&models.SnlPlayer{}).Where(&joined).First(&player).Error; err != nil {
+		logrus.Error("failed to fetch player: ", err)
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	// Only one request may send a prepared roll.
+	res := db.Model(&models.SnlPlayer{}).
+		Where(&joined).
+		Where("roll_transaction <> '' AND roll_transaction = ?", player.RollTransaction).
+		Update("roll_transaction", "")
+	if res.Error != nil {
+		logrus.Error("db err: ", res.Error)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": res.Error.Error()})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "roll is not waiting for a signature", "code": "ROLL_NOT_PREPARED"})
+		return
+	}
+
+	params := smartcontract.SnlRollDiceParams{
+		Module:          module,
+		GameID:          game.GameId,
+		AvatarAddress:   player.AvatarAddress,
+		PlayerPublicKey: req.PublicKey,
+	}
+	tx, err := h.chain.SubmitSigned(smartcontract.NewSnlRollTx(params, wallet, player.RollTransaction), req.PublicKey, req.Signature)
+	if err != nil {
+		// A roll the node never accepted can be signed again.
+		if errors.Is(err, smartcontract.ErrInvalidPublicKey) || errors.Is(err, smartcontract.ErrInvalidSignature) ||
+			errors.Is(err, smartcontract.ErrNotSubmitted) {
+			if err := db.Model(&models.SnlPlayer{}).Where(&joined).Where("roll_transaction = ''").
+				Update("roll_transaction", player.RollTransaction).Error; err != nil {
+				logrus.Error("db err: ", err)
+			}
+		}
+		apierror.Respond(c, err)
+		return
+	}
+	txHash := tx.Result.TransactionHash
+
+	var event indexer.RollDiceEvent
+	if err := h.indexer.TxEvent(c.Request.Context(), tx, module.EventType("RollDiceEvent"), &event); err != nil {
+		logrus.Errorf("failed to read roll of %s: %s", txHash, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error(), "data": txHash})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"number": strconv.Itoa(event.Number), "data": txHash})
+}
+
+// GetRolls lists the dice rolls of a game synced by the ingester.
+func (h *handler) GetRolls(c *gin.Context) {
+	gameId, err := strconv.Atoi(c.Query("gameId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid gameId"})
+		return
+	}
+	game, module, err := h.findGame(gameId, c.Query("module"))
+	if err != nil {
+		logrus.Error("failed to fetch game: ", err)
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	var rolls []models.SnlRoll
+	db := dbconfig.GetDb()
+	if err := db.Model(&models.SnlRoll{}).
+		Where("game_id = ? AND module = ?", game.GameId, module.ID()).
+		Order("transaction_version").
+		Find(&rolls).Error; err != nil {
+		logrus.Error("failed to fetch rolls: ", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, rolls)
+}
+
+// GameWon has the admin account declare the winner of a game on behalf of
+// the creator of the game or an operator, who prove it by signing
+// smartcontract.GameWonMessage. The winner must own the avatar. game_won does
+// not end the game, so only the winner is recorded.
+func (h *handler) GameWon(c *gin.Context) {
+	var req GameWonRequest
+	if err := c.BindJSON(&req); err != nil {
+		logrus.Error("failed to bind request: ", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	game, module, err := h.findGame(req.GameId, req.Module)
+	if err != nil {
+		logrus.Error("failed to fetch game: ", err)
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	signer, err := smartcontract.PlayerAddress(req.PublicKey)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+	allowed, err := h.canDeclareWinner(game, signer)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+	if !allowed {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the game creator or an operator can declare the winner", "code": "NOT_GAME_CREATOR"})
+		return
+	}
+	params := smartcontract.SnlGameWonParams{
+		Module:        module,
+		GameID:        game.GameId,
+		User:          req.WalletAddress,
+		AvatarAddress: req.AvatarAddress,
+		Snakes:        req.Snakes,
+		Ladders:       req.Ladders,
+	}
+	if err := smartcontract.VerifyMessage(req.PublicKey, smartcontract.GameWonMessage(params), req.FullMessage, req.Signature); err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+	owner, err := h.chain.AvatarOwner(smartcontract.CardParams{Module: module, GameID: game.GameId, Address: req.AvatarAddress})
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+	if !aptos.SameAddress(owner, req.WalletAddress) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "avatar does not belong to the wallet", "code": "NOT_AVATAR_OWNER"})
+		return
+	}
+	tx, err := h.chain.SnlGameWon(params)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+	txHash := tx.Result.TransactionHash
+	db := dbconfig.GetDb()
+	if err := db.Model(&models.SnlGame{}).
+		Where("game_id = ? AND module = ?", game.GameId, game.Module).
+		Update("winner", req.WalletAddress).Error; err != nil {
+		logrus.Error("db err: ", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "data": txHash})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": txHash})
+}
+
+// canDeclareWinner reports whether signer may have the winner of game
+// declared: its creator or one of the accounts the backend sends from.
+func (h *handler) canDeclareWinner(game *models.SnlGame, signer string) (bool, error) {
+	if aptos.SameAddress(signer, game.CreatorWalletAddress) {
+		return true, nil
+	}
+	operators, err := h.chain.Operators()
+	if err != nil {
+		return false, err
+	}
+	for _, o := range operators {
+		if aptos.SameAddress(signer, o.Address) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// CancelGame cancels a game that has not started on behalf of its creator,
+// who proves it by signing smartcontract.CancelMessage with their key.
+func (h *handler) CancelGame(c *gin.Context) {
+	var req CancelGameRequest
+	if err := c.BindJSON(&req); err != nil {
+		logrus.Error("failed to bind request: ", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	game, module, err := h.findGame(req.GameId, req.Module)
+	if err != nil {
+		logrus.Error("failed to fetch game: ", err)
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	creator, err := smartcontract.PlayerAddress(req.PublicKey)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+	if !aptos.SameAddress(creator, game.CreatorWalletAddress) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the game creator can cancel the game", "code": "NOT_GAME_CREATOR"})
+		return
+	}
+	if err := smartcontract.VerifyMessage(req.PublicKey, smartcontract.CancelMessage(module, game.GameId), req.FullMessage, req.Signature); err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+	tx, err := h.chain.SnlCancelGame(smartcontract.CancelGameParams{Module: module, GameID: game.GameId})
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+	txHash := tx.Result.TransactionHash
+	if err := updateGameStatus(game, models.GameStatusCancelled); err != nil {
+		logrus.Error("db err: ", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "data": txHash})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": txHash})
+}
+
+// GetStats reads the state of a game from the chain.
+func (h *handler) GetStats(c *gin.Context) {
+	gameId, err := strconv.Atoi(c.Query("gameId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid gameId"})
+		return
+	}
+	module, err := h.module(c.Query("module"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	state, err := h.chain.SnlGameState(smartcontract.GameStateParams{Module: module, GameID: gameId})
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+	prizePool := gin.H{"octas": state.PrizePool, "apt": state.PrizePoolAPT()}
+	if price, err := smartcontract.GetCoinPrice(); err != nil {
+		logrus.Error("failed to fetch APT price: ", err)
+	} else {
+		prizePool["usd"] = state.PrizePoolAPT() * price
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"gameId":            gameId,
+		"module":            module.ID(),
+		"cardsSold":         state.CardsSold,
+		"collectionAddress": state.CollectionAddress,
+		"prizePool":         prizePool,
+		"isStarted":         state.IsStarted,
+		"isFinished":        state.IsFinished,
+	})
 }
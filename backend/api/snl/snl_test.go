@@ -0,0 +1,304 @@
+package snl
+
+import (
+	"VirtueGaming/config/dbconfig"
+	"VirtueGaming/models"
+	"VirtueGaming/utils/indexer"
+	"VirtueGaming/utils/smartcontract"
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const testInterval = 10
+
+// testServer serves the snakes and ladders routes against a FakeChain, a
+// SQLite database and pins that never leave the process.
+type testServer struct {
+	t      *testing.T
+	router *gin.Engine
+	chain  *smartcontract.FakeChain
+	now    time.Time
+}
+
+func newTestServer(t *testing.T) *testServer {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dbconfig.SetDb(db)
+	t.Cleanup(func() { dbconfig.SetDb(nil) })
+	if err := dbconfig.DbInit(); err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	s := &testServer{t: t, router: gin.New(), chain: smartcontract.NewFakeChain("0xad"), now: time.Now()}
+	s.chain.Now = func() time.Time { return s.now }
+	s.chain.Seed(1)
+	pins := 0
+	h := &handler{
+		chain:   s.chain,
+		indexer: indexer.NewClient("http://indexer.invalid"),
+		pinMetadata: func(data []byte) (string, error) {
+			pins++
+			return "bafy" + strconv.Itoa(pins), nil
+		},
+	}
+	h.routes(s.router.Group(""))
+	return s
+}
+
+// do sends body as JSON, or as the query of a GET, and decodes the response.
+func (s *testServer) do(method, path string, body interface{}) (int, map[string]interface{}) {
+	s.t.Helper()
+	var req *http.Request
+	if method == http.MethodGet {
+		req = httptest.NewRequest(method, path, nil)
+	} else {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			s.t.Fatal(err)
+		}
+		req = httptest.NewRequest(method, path, bytes.NewReader(raw))
+		req.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	var out map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+		s.t.Fatalf("%s %s: invalid response %q", method, path, w.Body.String())
+	}
+	return w.Code, out
+}
+
+// expect sends a request and fails unless it is answered with status.
+func (s *testServer) expect(status int, method, path string, body interface{}) map[string]interface{} {
+	s.t.Helper()
+	code, out := s.do(method, path, body)
+	if code != status {
+		s.t.Fatalf("%s %s: status %d, want %d: %v", method, path, code, status, out)
+	}
+	return out
+}
+
+func (s *testServer) createGame(creator ed25519.PublicKey) int {
+	s.t.Helper()
+	out := s.expect(http.StatusOK, http.MethodPost, "/snl", CreateGameRequest{
+		Name:                 "Friday snakes",
+		StartTimestamp:       strconv.FormatInt(s.now.Unix()+60, 10),
+		CreatorWalletAddress: address(s.t, creator),
+		MintPrice:            "1 APT",
+		Interval:             testInterval,
+	})
+	id, err := strconv.Atoi(out["gameId"].(string))
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	return id
+}
+
+// join buys an avatar for player and returns its address.
+func (s *testServer) join(gameID int, player ed25519.PrivateKey) string {
+	s.t.Helper()
+	pub := hexKey(player)
+	out := s.expect(http.StatusOK, http.MethodPost, "/snl/join", JoinGameRequest{GameId: gameID, PublicKey: pub})
+	out = s.expect(http.StatusOK, http.MethodPost, "/snl/join/submit", SubmitJoinRequest{
+		GameId:    gameID,
+		PublicKey: pub,
+		Signature: signHex(s.t, player, out["signingMessage"].(string)),
+	})
+	return out["avatarAddress"].(string)
+}
+
+func (s *testServer) start(gameID int) {
+	s.t.Helper()
+	s.now = s.now.Add(time.Minute)
+	s.expect(http.StatusOK, http.MethodPost, "/snl/start", GameRequest{GameId: gameID})
+}
+
+func testKey(seed byte) ed25519.PrivateKey {
+	return ed25519.NewKeyFromSeed(bytes.Repeat([]byte{seed}, ed25519.SeedSize))
+}
+
+func hexKey(key ed25519.PrivateKey) string {
+	return "0x" + hex.EncodeToString(key.Public().(ed25519.PublicKey))
+}
+
+func address(t *testing.T, pub ed25519.PublicKey) string {
+	addr, err := smartcontract.PlayerAddress(hex.EncodeToString(pub))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return addr
+}
+
+func signHex(t *testing.T, key ed25519.PrivateKey, message string) string {
+	msg, err := hex.DecodeString(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hex.EncodeToString(ed25519.Sign(key, msg))
+}
+
+func TestGameFlow(t *testing.T) {
+	s := newTestServer(t)
+	creator, player, other := testKey(1), testKey(2), testKey(3)
+	playerAddress := address(t, player.Public().(ed25519.PublicKey))
+	s.chain.Fund(playerAddress, 10*100000000)
+
+	gameID := s.createGame(creator.Public().(ed25519.PublicKey))
+	var game models.SnlGame
+	if err := dbconfig.GetDb().Where("game_id = ?", gameID).First(&game).Error; err != nil {
+		t.Fatal(err)
+	}
+	if game.MintPrice != 100000000 || game.Interval != testInterval || game.CollectionUri != "ipfs://bafy1" {
+		t.Errorf("game saved with mint price %d, interval %d and collection %q", game.MintPrice, game.Interval, game.CollectionUri)
+	}
+
+	avatar := s.join(gameID, player)
+	state, _ := s.chain.SnlGame(gameID)
+	if len(state.Avatars) != 1 || state.Avatars[0] != avatar {
+		t.Fatalf("joined with avatar %q, the game has %v", avatar, state.Avatars)
+	}
+
+	roll := RollDiceRequest{GameId: gameID, PublicKey: hexKey(player)}
+	out := s.expect(http.StatusConflict, http.MethodPost, "/snl/rollDice", roll)
+	if out["code"] != "GAME_NOT_STARTED" {
+		t.Errorf("roll before the start: got %v", out)
+	}
+	s.start(gameID)
+
+	out = s.expect(http.StatusOK, http.MethodPost, "/snl/rollDice", roll)
+	if out["avatarAddress"] != avatar {
+		t.Errorf("roll prepared for avatar %v, want %s", out["avatarAddress"], avatar)
+	}
+	submit := SubmitRollRequest{
+		GameId:    gameID,
+		PublicKey: hexKey(player),
+		Signature: signHex(t, player, out["signingMessage"].(string)),
+	}
+	out = s.expect(http.StatusOK, http.MethodPost, "/snl/rollDice/submit", submit)
+	if n, err := strconv.Atoi(out["number"].(string)); err != nil || n < 2 || n > 12 {
+		t.Errorf("rolled %v", out["number"])
+	}
+	// The roll is sent once.
+	out = s.expect(http.StatusConflict, http.MethodPost, "/snl/rollDice/submit", submit)
+	if out["code"] != "ROLL_NOT_PREPARED" {
+		t.Errorf("roll sent twice: got %v", out)
+	}
+	out = s.expect(http.StatusTooManyRequests, http.MethodPost, "/snl/rollDice", roll)
+	if out["code"] != "NEED_TO_WAIT_INTERVAL_TIME" {
+		t.Errorf("roll within the interval: got %v", out)
+	}
+
+	won := func(key ed25519.PrivateKey, wallet string) GameWonRequest {
+		req := GameWonRequest{GameId: gameID, WalletAddress: wallet, AvatarAddress: avatar, Snakes: 2, Ladders: 3, PublicKey: hexKey(key)}
+		message := smartcontract.GameWonMessage(smartcontract.SnlGameWonParams{
+			Module:        s.chain.SnlModule(),
+			GameID:        gameID,
+			User:          wallet,
+			AvatarAddress: avatar,
+			Snakes:        req.Snakes,
+			Ladders:       req.Ladders,
+		})
+		req.Signature = hex.EncodeToString(ed25519.Sign(key, []byte(message)))
+		return req
+	}
+	out = s.expect(http.StatusForbidden, http.MethodPost, "/snl/gameWon", won(other, playerAddress))
+	if out["code"] != "NOT_GAME_CREATOR" {
+		t.Errorf("winner declared by another wallet: got %v", out)
+	}
+	out = s.expect(http.StatusForbidden, http.MethodPost, "/snl/gameWon", won(creator, address(t, other.Public().(ed25519.PublicKey))))
+	if out["code"] != "NOT_AVATAR_OWNER" {
+		t.Errorf("winner without the avatar: got %v", out)
+	}
+	s.expect(http.StatusOK, http.MethodPost, "/snl/gameWon", won(creator, playerAddress))
+
+	if err := dbconfig.GetDb().Where("game_id = ?", gameID).First(&game).Error; err != nil {
+		t.Fatal(err)
+	}
+	// game_won does not end the game.
+	if game.Winner != playerAddress || game.Status != models.GameStatusStarted {
+		t.Errorf("game saved with winner %q and status %q", game.Winner, game.Status)
+	}
+	if state, _ := s.chain.SnlGame(gameID); state.Winner != playerAddress {
+		t.Errorf("winner on chain %q, want %q", state.Winner, playerAddress)
+	}
+}
+
+func TestCreateGameParams(t *testing.T) {
+	s := newTestServer(t)
+	valid := CreateGameRequest{
+		Name:           "Friday snakes",
+		StartTimestamp: strconv.FormatInt(s.now.Unix()+60, 10),
+		MintPrice:      "1 APT",
+		Interval:       testInterval,
+	}
+	tests := []struct {
+		name string
+		edit func(*CreateGameRequest)
+	}{
+		{"no mint price", func(r *CreateGameRequest) { r.MintPrice = "" }},
+		{"no interval", func(r *CreateGameRequest) { r.Interval = 0 }},
+		{"royalty over 100", func(r *CreateGameRequest) { r.RoyaltyNumerator = 101 }},
+		{"invalid start", func(r *CreateGameRequest) { r.StartTimestamp = "soon" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid
+			tt.edit(&req)
+			out := s.expect(http.StatusBadRequest, http.MethodPost, "/snl", req)
+			if out["code"] != "INVALID_GAME_PARAMS" {
+				t.Errorf("got %v", out)
+			}
+		})
+	}
+}
+
+func TestCancelGame(t *testing.T) {
+	s := newTestServer(t)
+	creator, player := testKey(1), testKey(2)
+	playerAddress := address(t, player.Public().(ed25519.PublicKey))
+	s.chain.Fund(playerAddress, 100000000)
+	gameID := s.createGame(creator.Public().(ed25519.PublicKey))
+	s.join(gameID, player)
+
+	message := smartcontract.CancelMessage(s.chain.SnlModule(), gameID)
+	cancel := func(key ed25519.PrivateKey) CancelGameRequest {
+		return CancelGameRequest{
+			GameId:    gameID,
+			PublicKey: hexKey(key),
+			Signature: hex.EncodeToString(ed25519.Sign(key, []byte(message))),
+		}
+	}
+	out := s.expect(http.StatusForbidden, http.MethodPost, "/snl/cancel", cancel(player))
+	if out["code"] != "NOT_GAME_CREATOR" {
+		t.Errorf("cancel by a player: got %v", out)
+	}
+	s.expect(http.StatusOK, http.MethodPost, "/snl/cancel", cancel(creator))
+
+	var game models.SnlGame
+	if err := dbconfig.GetDb().Where("game_id = ?", gameID).First(&game).Error; err != nil {
+		t.Fatal(err)
+	}
+	if game.Status != models.GameStatusCancelled {
+		t.Errorf("game status %q, want %q", game.Status, models.GameStatusCancelled)
+	}
+	// The avatar is refunded.
+	if balance := s.chain.Balances[playerAddress]; balance != 100000000 {
+		t.Errorf("player balance %d after the cancel, want 100000000", balance)
+	}
+}
@@ -2,14 +2,101 @@ package snl
 
 type CreateGameRequest struct {
 	Name                 string `json:"name"`
+	StartTimestamp       string `json:"startTimestamp"`
 	Symbol               string `json:"symbol"`
 	Picture              string `json:"picture"`
 	CoverImage           string `json:"coverImage"`
 	Description          string `json:"description"`
 	CreatorWalletAddress string `json:"creatorWalletAddress"`
 	Type                 string `json:"type"`
+	// MintPrice is the price of an avatar, in APT ("1.5 APT") or in octas
+	// ("150000000").
+	MintPrice string `json:"mintPrice"`
+	// Interval is the minimum number of seconds between two rolls of an
+	// avatar.
+	Interval uint64 `json:"interval"`
+	// The collection of the avatars defaults to the name and description of
+	// the game, and to collection metadata pinned from the game details.
+	CollectionName        string `json:"collectionName"`
+	CollectionDescription string `json:"collectionDescription"`
+	CollectionUri         string `json:"collectionUri"`
+	// RoyaltyNumerator is the royalty on avatar sales in percent.
+	RoyaltyNumerator uint64 `json:"royaltyNumerator"`
 }
 
 type GetGameReqest struct {
 	GameId int `json:"gameId"`
 }
+
+type GameRequest struct {
+	GameId int    `json:"gameId"`
+	Module string `json:"module"`
+}
+
+// JoinGameRequest prepares the join of the player of PublicKey, the hex
+// encoded key of their wallet.
+type JoinGameRequest struct {
+	GameId    int    `json:"gameId"`
+	Module    string `json:"module"`
+	PublicKey string `json:"publicKey"`
+}
+
+// SubmitJoinRequest carries the player's signature of the transaction
+// JoinGame returned for the same game.
+type SubmitJoinRequest struct {
+	GameId    int    `json:"gameId"`
+	Module    string `json:"module"`
+	PublicKey string `json:"publicKey"`
+	Signature string `json:"signature"`
+}
+
+// CancelGameRequest carries the signature of the creator of the game of
+// smartcontract.CancelMessage, see the bingo CancelGameRequest.
+type CancelGameRequest struct {
+	GameId      int    `json:"gameId"`
+	Module      string `json:"module"`
+	PublicKey   string `json:"publicKey"`
+	Signature   string `json:"signature"`
+	FullMessage string `json:"fullMessage"`
+}
+
+// RollDiceRequest prepares a roll of the avatar the player of PublicKey
+// bought when joining.
+type RollDiceRequest struct {
+	GameId    int    `json:"gameId"`
+	Module    string `json:"module"`
+	PublicKey string `json:"publicKey"`
+}
+
+// SubmitRollRequest carries the player's signature of the transaction
+// RollDice returned for the same game.
+type SubmitRollRequest struct {
+	GameId    int    `json:"gameId"`
+	Module    string `json:"module"`
+	PublicKey string `json:"publicKey"`
+	Signature string `json:"signature"`
+}
+
+// GameWonRequest declares the winner of a game, with the signature of
+// smartcontract.GameWonMessage by the creator of the game or an operator,
+// made with the key of PublicKey. FullMessage is the message the wallet
+// actually signed, when it wrapped it.
+type GameWonRequest struct {
+	GameId        int    `json:"gameId"`
+	Module        string `json:"module"`
+	WalletAddress string `json:"walletAddress"`
+	AvatarAddress string `json:"avatarAddress"`
+	Snakes        int    `json:"snakes"`
+	Ladders       int    `json:"ladders"`
+	PublicKey     string `json:"publicKey"`
+	Signature     string `json:"signature"`
+	FullMessage   string `json:"fullMessage"`
+}
+
+type AvatarMetadata struct {
+	GameId      int    `json:"gameId"`
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Image       string `json:"image"`
+}
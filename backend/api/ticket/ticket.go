@@ -1,13 +1,13 @@
 package ticket
 
 import (
+	"VirtueGaming/api/apierror"
 	"VirtueGaming/models"
 	"VirtueGaming/utils"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"os"
-	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
@@ -17,9 +17,12 @@ func ApplyRoutes(r *gin.RouterGroup) {
 	g := r.Group("/ticket")
 	{
 		g.POST("", generateTicket)
+		g.POST("/strip", generateStrip)
 	}
 }
 
+// generateTicket generates a card in the format of req.Type, and renders and
+// pins it.
 func generateTicket(c *gin.Context) {
 	var req PostTickerRequest
 	if err := c.BindJSON(&req); err != nil {
@@ -27,49 +30,77 @@ func generateTicket(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	format := utils.FormatForType(req.Type)
+	pinned, step, err := pinTicket(req, format, format.Generate(utils.RandomTicketSeed()))
+	if err != nil {
+		apierror.RespondStep(c, step, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data": pinned,
+	})
+}
+
+// Steps of pinning a ticket, reported back to the client when one fails.
+const (
+	stepRender      = "render_ticket"
+	stepPinImage    = "pin_image"
+	stepPinMetadata = "pin_metadata"
+)
 
-	ticket := utils.Generate()
-	ticketString := utils.IntArrayToStringArray(ticket)
-	flatTicket := utils.FlattenTicket(ticketString)
+// generateStrip generates a strip of six tickets holding every number from 1
+// to 90 once, and renders and pins each of them.
+func generateStrip(c *gin.Context) {
+	var req PostTickerRequest
+	if err := c.BindJSON(&req); err != nil {
+		logrus.Error("failed to bind request: ", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	strip := utils.GenerateStrip()
+	tickets := make([]PinnedTicket, 0, len(strip))
+	for i, ticket := range strip {
+		pinned, step, err := pinTicket(req, utils.NinetyBall, utils.TicketCard(ticket))
+		if err != nil {
+			apierror.RespondStep(c, step, fmt.Errorf("ticket %d of the strip: %w", i+1, err))
+			return
+		}
+		tickets = append(tickets, *pinned)
+	}
+	c.JSON(http.StatusOK, gin.H{"data": tickets})
+}
 
-	ticketStringNonZero := utils.ReplaceZeroWithEmpty(ticketString)
+// pinTicket renders card, of format f, and pins its image and metadata. On
+// failure it returns the step that failed.
+func pinTicket(req PostTickerRequest, f utils.CardFormat, card utils.Card) (*PinnedTicket, string, error) {
+	if err := f.Validate(card); err != nil {
+		return nil, stepRender, err
+	}
+	flatTicket := card.Flatten()
 
-	imgbytes, err := utils.CreateTicketBytes(ticketStringNonZero, "image.png")
+	imgbytes, err := utils.CreateTicketBytes(f.Cells(card), "image.png")
 	if err != nil {
-		fmt.Println("err: ", err)
-		return
+		return nil, stepRender, err
 	}
 	imageIPFSHash, err := utils.UploadImageToNFTStorage(os.Getenv("NFT_STORAGE_KEY"), imgbytes)
 	if err != nil {
-		fmt.Println("err: ", err)
-		return
+		return nil, stepPinImage, err
 	}
-	metadata := models.Metadata{
+	metadataBytes, err := json.Marshal(models.Metadata{
 		GameId:      req.GameId,
 		Type:        req.Type,
 		Name:        req.Name,
 		Description: req.Description,
-		Ticket:      strings.Join(flatTicket, ","),
+		Format:      f.Name(),
+		Ticket:      flatTicket,
 		Image:       "ipfs://" + imageIPFSHash + "/image.jpeg",
-	}
-	metadataBytes, err := json.Marshal(metadata)
+	})
 	if err != nil {
-		fmt.Println("err: ", err)
-		return
+		return nil, stepPinMetadata, err
 	}
-
 	metadataHash, err := utils.UploadMetadataToNFTStorage(os.Getenv("NFT_STORAGE_KEY"), metadataBytes)
 	if err != nil {
-		fmt.Println("err: ", err)
-		return
-	}
-
-	response := map[string]string{
-		"ticket":   strings.Join(flatTicket, ","),
-		"metadata": "ipfs://" + metadataHash,
+		return nil, stepPinMetadata, err
 	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"data": response,
-	})
+	return &PinnedTicket{Ticket: flatTicket, Metadata: "ipfs://" + metadataHash}, "", nil
 }
@@ -14,3 +14,9 @@ type Ticket struct {
 	Type        string `json:"type"`
 	Image       string `json:"image"`
 }
+
+// PinnedTicket is a ticket whose image and metadata are pinned.
+type PinnedTicket struct {
+	Ticket   string `json:"ticket"`
+	Metadata string `json:"metadata"`
+}
@@ -0,0 +1,166 @@
+package tx
+
+import (
+	"VirtueGaming/config/dbconfig"
+	"VirtueGaming/models"
+	"VirtueGaming/utils/aptos"
+	"VirtueGaming/utils/smartcontract"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type handler struct {
+	chain smartcontract.ChainClient
+}
+
+func ApplyRoutes(r *gin.RouterGroup, chain smartcontract.ChainClient) {
+	h := &handler{chain: chain}
+	g := r.Group("/tx")
+	{
+		g.GET("/gas", h.GetGasReport)
+		g.GET("/queue", h.GetQueue)
+		g.GET("/operators", h.GetOperators)
+		g.GET("/:hash", GetTransaction)
+	}
+}
+
+func GetTransaction(c *gin.Context) {
+	var tx models.Transaction
+	db := dbconfig.GetDb()
+	if err := db.Model(&models.Transaction{}).Where("hash = ?", c.Param("hash")).First(&tx).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "transaction not found"})
+			return
+		}
+		logrus.Error("failed to fetch transaction: ", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"hash":           tx.Hash,
+		"module":         tx.Module,
+		"function":       tx.Function,
+		"gameId":         tx.GameId,
+		"params":         json.RawMessage(tx.Params),
+		"sender":         tx.Sender,
+		"sequenceNumber": tx.SequenceNumber,
+		"status":         tx.Status,
+		"vmStatus":       tx.VMStatus,
+		"version":        tx.Version,
+		"gasUsed":        tx.GasUsed,
+		"gasUnitPrice":   tx.GasUnitPrice,
+		"gasCost":        tx.GasCost,
+		"createdAt":      tx.CreatedAt,
+		"updatedAt":      tx.UpdatedAt,
+	})
+}
+
+// GasReport is the gas spent on the calls of one function for a game.
+type GasReport struct {
+	Function     string `json:"function"`
+	Transactions int64  `json:"transactions"`
+	Failed       int64  `json:"failed"`
+	GasUsed      int64  `json:"gasUsed"`
+	GasCost      int64  `json:"gasCost"`
+}
+
+// GetGasReport sums the gas of the committed transactions of a game per
+// function. The module defaults to the bingo module.
+func (h *handler) GetGasReport(c *gin.Context) {
+	gameId, err := strconv.Atoi(c.Query("gameId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid gameId"})
+		return
+	}
+	module := h.chain.BingoModule()
+	if id := c.Query("module"); id != "" {
+		if module, err = smartcontract.ParseModule(id); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	var reports []GasReport
+	db := dbconfig.GetDb()
+	if err := db.Model(&models.Transaction{}).
+		Select("function, COUNT(*) AS transactions, COUNT(*) FILTER (WHERE status = ?) AS failed, SUM(gas_used) AS gas_used, SUM(gas_cost) AS gas_cost",
+			models.TransactionStatusFailed).
+		Where("game_id = ? AND module = ? AND status IN ?", gameId, module.ID(),
+			[]string{models.TransactionStatusConfirmed, models.TransactionStatusFailed}).
+		Group("function").
+		Order("function").
+		Scan(&reports).Error; err != nil {
+		logrus.Error("failed to sum gas: ", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	var total GasReport
+	for _, r := range reports {
+		total.Transactions += r.Transactions
+		total.Failed += r.Failed
+		total.GasUsed += r.GasUsed
+		total.GasCost += r.GasCost
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"gameId":    gameId,
+		"module":    module.ID(),
+		"functions": reports,
+		"total":     total,
+	})
+}
+
+// GetQueue reports how many calls are waiting to be submitted.
+func (h *handler) GetQueue(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"depth": h.chain.QueueDepth()})
+}
+
+// GetOperators lists the accounts sending transactions with their balance and
+// the number of transactions each of them sent.
+func (h *handler) GetOperators(c *gin.Context) {
+	operators, err := h.chain.Operators()
+	if err != nil {
+		logrus.Error("failed to fetch operators: ", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	var counts []struct {
+		Sender string
+		Count  int64
+	}
+	db := dbconfig.GetDb()
+	if err := db.Model(&models.Transaction{}).
+		Select("sender, COUNT(*) AS count").
+		Group("sender").
+		Scan(&counts).Error; err != nil {
+		logrus.Error("failed to count transactions: ", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	sent := make(map[string]int64, len(counts))
+	for _, n := range counts {
+		if a, err := aptos.ParseAddress(n.Sender); err == nil {
+			sent[a.String()] += n.Count
+		}
+	}
+	result := make([]gin.H, len(operators))
+	for i, o := range operators {
+		var transactions int64
+		if a, err := aptos.ParseAddress(o.Address); err == nil {
+			transactions = sent[a.String()]
+		}
+		result[i] = gin.H{
+			"address":      o.Address,
+			"admin":        o.Admin,
+			"balance":      o.Balance,
+			"queueDepth":   o.QueueDepth,
+			"low":          o.Low,
+			"transactions": transactions,
+		}
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -45,10 +45,18 @@ func GetDb() *gorm.DB {
 	return db.Debug()
 }
 
+// SetDb replaces the instance GetDb returns, so tests can run against their
+// own database.
+func SetDb(d *gorm.DB) {
+	db = d
+}
+
 func DbInit() error {
 	db := GetDb()
 
-	if err := db.AutoMigrate(&models.Game{}, &models.MemoryGame{}, &models.SnlGame{}); err != nil {
+	if err := db.AutoMigrate(&models.Game{}, &models.MemoryGame{}, &models.SnlGame{}, &models.Ticket{}, &models.Claim{}, &models.DrawnNumber{}, &models.SnlPlayer{},
+		&models.SnlRoll{}, &models.ChainEvent{}, &models.EventCheckpoint{}, &models.GameLease{}, &models.Transaction{}, &models.Sponsorship{},
+		&models.IssuedTicket{}); err != nil {
 		log.Fatal(err)
 	}
 	return nil
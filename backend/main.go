@@ -3,6 +3,16 @@ package main
 import (
 	"VirtueGaming/api"
 	"VirtueGaming/config/dbconfig"
+	"VirtueGaming/utils"
+	"VirtueGaming/utils/indexer"
+	"VirtueGaming/utils/ingester"
+	"VirtueGaming/utils/registry"
+	"VirtueGaming/utils/scheduler"
+	"VirtueGaming/utils/smartcontract"
+	"VirtueGaming/utils/sponsor"
+	"VirtueGaming/utils/txledger"
+	"context"
+	"log"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -12,6 +22,40 @@ import (
 func main() {
 	godotenv.Load()
 	dbconfig.DbInit()
+	chain, err := smartcontract.NewAptosClientFromEnv()
+	if err != nil {
+		log.Fatal("failed to create aptos client: ", err)
+	}
+	ledger := txledger.New(dbconfig.GetDb())
+	chain.SetRecorder(ledger)
+	go ledger.Poll(context.Background(), chain)
+	go chain.MonitorBalances(context.Background())
+	budget, err := sponsor.NewFromEnv(dbconfig.GetDb())
+	if err != nil {
+		log.Fatal(err)
+	}
+	seeder, err := utils.NewTicketSeederFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	idx, err := indexer.NewClientFromEnv()
+	if err != nil {
+		log.Fatal("failed to create indexer client: ", err)
+	}
+	interval, err := ingester.IntervalFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	ing := ingester.New(idx, dbconfig.GetDb(), chain.BingoModule(), chain.SnlModule())
+	ing.Interval = interval
+	go ing.Run(context.Background())
+	drawInterval, err := scheduler.DrawIntervalFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	sched := scheduler.New(chain, dbconfig.GetDb())
+	sched.DrawInterval = drawInterval
+	go sched.Run(context.Background())
 	ginApp := gin.Default()
 	// cors middleware
 	config := cors.DefaultConfig()
@@ -22,7 +66,7 @@ func main() {
 	ginApp.NoRoute(func(c *gin.Context) {
 		c.JSON(404, gin.H{"status": 404, "message": "Invalid Endpoint Request"})
 	})
-	api.ApplyRoutes(ginApp)
+	api.ApplyRoutes(ginApp, chain, idx, budget, seeder, registry.New(dbconfig.GetDb(), seeder))
 	// ginApp.Run(":" + os.Getenv("HTTP_PORT"))
 	ginApp.Run(":" + "8070")
 
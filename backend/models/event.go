@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// ChainEvent is an event of the bingo or SNL module synced from the indexer.
+type ChainEvent struct {
+	Module             string `json:"module" gorm:"index"`
+	Type               string `json:"type"`
+	GameId             int    `json:"gameId" gorm:"index"`
+	TransactionVersion int64  `json:"transactionVersion" gorm:"uniqueIndex:idx_chain_event_position"`
+	EventIndex         int64  `json:"eventIndex" gorm:"uniqueIndex:idx_chain_event_position"`
+	Data               string `json:"data" gorm:"type:jsonb"`
+}
+
+// EventCheckpoint is the last event of a module the ingester has applied.
+type EventCheckpoint struct {
+	Module             string `gorm:"primaryKey"`
+	TransactionVersion int64
+	EventIndex         int64
+	UpdatedAt          time.Time
+}
+
+// GameLease gives one replica the right to drive a game until ExpiresAt.
+type GameLease struct {
+	Module    string `gorm:"primaryKey"`
+	GameId    int    `gorm:"primaryKey;autoIncrement:false"`
+	Owner     string
+	ExpiresAt time.Time
+}
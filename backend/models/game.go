@@ -2,6 +2,14 @@ package models
 
 import "github.com/lib/pq"
 
+// Status values of a bingo or snakes and ladders game.
+const (
+	GameStatusNotStarted = "notStarted"
+	GameStatusStarted    = "started"
+	GameStatusFinished   = "finished"
+	GameStatusCancelled  = "cancelled"
+)
+
 type Game struct {
 	Name                 string `json:"name"`
 	StartTimestamp       string `json:"startTimestamp"`
@@ -13,6 +21,21 @@ type Game struct {
 	Type                 string `json:"type"`
 	TransactionHash      string `json:"transactionHash"`
 	GameId               int    `json:"gameId"`
+	Module               string `json:"module"`
+	Status               string `json:"status"`
+	// The create_game arguments, the mint price in octas and the interval
+	// in seconds.
+	MintPrice             int64  `json:"mintPrice"`
+	Interval              int64  `json:"interval"`
+	CollectionName        string `json:"collectionName"`
+	CollectionDescription string `json:"collectionDescription"`
+	CollectionUri         string `json:"collectionUri"`
+	RoyaltyNumerator      int64  `json:"royaltyNumerator"`
+	// Kept up to date from the chain events by the ingester.
+	CardsSold    int            `json:"cardsSold"`
+	LastDrawnAt  int64          `json:"lastDrawnAt"`
+	DrawnNumbers pq.Int64Array  `json:"drawnNumbers" gorm:"type:bigint[]"`
+	Winners      pq.StringArray `json:"winners" gorm:"type:text[]"`
 }
 type MemoryGame struct {
 	Name                 string         `json:"name"`
@@ -31,6 +54,7 @@ type MemoryGame struct {
 
 type SnlGame struct {
 	Name                 string `json:"name"`
+	StartTimestamp       string `json:"startTimestamp"`
 	Symbol               string `json:"symbol"`
 	Picture              string `json:"picture"`
 	CoverImage           string `json:"coverImage"`
@@ -39,4 +63,50 @@ type SnlGame struct {
 	Type                 string `json:"type"`
 	TransactionHash      string `json:"transactionHash"`
 	GameId               int    `json:"gameId"`
+	Module               string `json:"module"`
+	Status               string `json:"status"`
+	// The create_game arguments, see Game.
+	MintPrice             int64  `json:"mintPrice"`
+	Interval              int64  `json:"interval"`
+	CollectionName        string `json:"collectionName"`
+	CollectionDescription string `json:"collectionDescription"`
+	CollectionUri         string `json:"collectionUri"`
+	RoyaltyNumerator      int64  `json:"royaltyNumerator"`
+	// Kept up to date from the chain events by the ingester.
+	Players int    `json:"players"`
+	Winner  string `json:"winner"`
+}
+
+// Status values of a snakes and ladders player.
+const (
+	// SnlPlayerStatusPrepared is a join the player has not signed yet.
+	SnlPlayerStatusPrepared = "prepared"
+	SnlPlayerStatusJoined   = "joined"
+)
+
+// SnlPlayer is an avatar bought by a player through /snl/join, once signed
+// through /snl/join/submit.
+type SnlPlayer struct {
+	GameId          int    `json:"gameId"`
+	Module          string `json:"module"`
+	WalletAddress   string `json:"walletAddress"`
+	AvatarAddress   string `json:"avatarAddress"`
+	MetadataUri     string `json:"metadataUri"`
+	TransactionHash string `json:"transactionHash"`
+	Status          string `json:"status"`
+	// RawTransaction is the transaction the player signs, see
+	// smartcontract.PlayerTx.
+	RawTransaction string `json:"-"`
+	// RollTransaction is the roll_dice transaction waiting for the player's
+	// signature, once joined.
+	RollTransaction string `json:"-"`
+}
+
+// SnlRoll is a dice roll of a snakes and ladders game.
+type SnlRoll struct {
+	GameId             int    `json:"gameId"`
+	Module             string `json:"module"`
+	User               string `json:"user"`
+	Number             int    `json:"number"`
+	TransactionVersion int64  `json:"transactionVersion"`
 }
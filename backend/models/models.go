@@ -1,13 +1,87 @@
 package models
 
+import "time"
+
 type Metadata struct {
 	GameId      int    `json:"gameId"`
 	Type        string `json:"type"`
 	Name        string `json:"name"`
 	Description string `json:"description"`
-	Ticket      string `json:"ticket"`
+	// Format is the card format of Ticket, see utils.FormatForType.
+	Format string `json:"format"`
+	Ticket string `json:"ticket"`
+	// Nonce is needed with the game secret to verify Ticket.
+	Nonce int    `json:"nonce"`
+	Image string `json:"image"`
+}
+
+// CollectionMetadata is the JSON the collection URI of a game points to.
+type CollectionMetadata struct {
+	Name        string `json:"name"`
+	Symbol      string `json:"symbol"`
+	Description string `json:"description"`
 	Image       string `json:"image"`
+	// CoverImage is shown by wallets as the banner of the collection.
+	CoverImage string `json:"banner_image"`
 }
 
 type TicketRequest struct {
 }
+
+// Ticket is a bingo card bought by a player through /game/join.
+type Ticket struct {
+	GameId          int    `json:"gameId"`
+	Module          string `json:"module"`
+	WalletAddress   string `json:"walletAddress"`
+	Ticket          string `json:"ticket"`
+	MetadataUri     string `json:"metadataUri"`
+	TransactionHash string `json:"transactionHash"`
+	CardAddress     string `json:"cardAddress"`
+}
+
+// IssuedTicket is a card handed out for a game. A grid is issued to one wallet
+// per game only, so every card handed out can join.
+type IssuedTicket struct {
+	Module        string `json:"module" gorm:"uniqueIndex:idx_issued_ticket_grid"`
+	GameId        int    `json:"gameId" gorm:"uniqueIndex:idx_issued_ticket_grid"`
+	Hash          string `json:"hash" gorm:"uniqueIndex:idx_issued_ticket_grid"`
+	WalletAddress string `json:"walletAddress" gorm:"index"`
+	Ticket        string `json:"ticket"`
+	// Nonce is the attempt the ticket was generated at, see utils.NewTicketSeed.
+	Nonce     int       `json:"nonce"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Status values of a prize claim.
+const (
+	// ClaimStatusPrepared is a claim the player has not signed yet.
+	ClaimStatusPrepared = "prepared"
+	ClaimStatusPending  = "pending"
+	ClaimStatusPaid     = "paid"
+	ClaimStatusFailed   = "failed"
+)
+
+// Claim is a prize claim prepared through /game/claim and signed by the
+// player through /game/claim/submit. It stays pending until the next draw
+// distributes the prize.
+type Claim struct {
+	GameId             int    `json:"gameId"`
+	Module             string `json:"module"`
+	WalletAddress      string `json:"walletAddress"`
+	CardAddress        string `json:"cardAddress"`
+	Prize              string `json:"prize"`
+	Status             string `json:"status"`
+	TransactionHash    string `json:"transactionHash"`
+	TransactionVersion int64  `json:"transactionVersion"`
+	// RawTransaction is the transaction the player signs, see
+	// smartcontract.PlayerTx.
+	RawTransaction string `json:"-"`
+}
+
+// DrawnNumber is a number drawn for a bingo game.
+type DrawnNumber struct {
+	GameId          int    `json:"gameId"`
+	Module          string `json:"module"`
+	Number          int    `json:"number"`
+	TransactionHash string `json:"transactionHash"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// Status values of a sponsorship.
+const (
+	// SponsorshipStatusReserved is a join the player has not signed yet. It
+	// can no longer be sent after ExpiresAt.
+	SponsorshipStatusReserved  = "reserved"
+	SponsorshipStatusSubmitted = "submitted"
+	SponsorshipStatusConfirmed = "confirmed"
+	SponsorshipStatusFailed    = "failed"
+	// SponsorshipStatusReleased is a join that was never submitted and no
+	// longer counts against the budgets.
+	SponsorshipStatusReleased = "released"
+)
+
+// Sponsorship is a join_game transaction prepared for the player to sign.
+// The backend pays its gas when FeePayer is set, otherwise its Cost is 0 and
+// it does not count against the budgets.
+type Sponsorship struct {
+	ID            uint   `json:"id" gorm:"primaryKey"`
+	Module        string `json:"module" gorm:"index:idx_sponsorship_game"`
+	GameId        int    `json:"gameId" gorm:"index:idx_sponsorship_game"`
+	WalletAddress string `json:"walletAddress" gorm:"index"`
+	// Ticket and MetadataUri are the card the player buys.
+	Ticket         string `json:"ticket"`
+	MetadataUri    string `json:"metadataUri"`
+	RawTransaction string `json:"rawTransaction"`
+	FeePayer       string `json:"feePayer"`
+	Status         string `json:"status" gorm:"index"`
+	// Cost is the max gas cost of the transaction until it is committed,
+	// then the gas it cost, in octas.
+	Cost            int64     `json:"cost"`
+	TransactionHash string    `json:"transactionHash"`
+	ExpiresAt       time.Time `json:"expiresAt"`
+	CreatedAt       time.Time `json:"createdAt"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+}
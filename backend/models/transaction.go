@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Status values of a transaction.
+const (
+	TransactionStatusPending   = "pending"
+	TransactionStatusConfirmed = "confirmed"
+	TransactionStatusFailed    = "failed"
+	// TransactionStatusExpired is a transaction the node never committed
+	// before its expiration.
+	TransactionStatusExpired = "expired"
+)
+
+// Transaction is an entry function call submitted by the backend.
+type Transaction struct {
+	Hash     string `json:"hash" gorm:"primaryKey"`
+	Module   string `json:"module" gorm:"index:idx_transaction_game"`
+	Function string `json:"function"`
+	// GameId is nil for a create_game call that did not go through.
+	GameId         *int   `json:"gameId" gorm:"index:idx_transaction_game"`
+	Params         string `json:"params" gorm:"type:jsonb"`
+	Sender         string `json:"sender" gorm:"index"`
+	SequenceNumber int64  `json:"sequenceNumber"`
+	Status         string `json:"status" gorm:"index"`
+	VMStatus       string `json:"vmStatus"`
+	Version        int64  `json:"version"`
+	GasUsed        int64  `json:"gasUsed"`
+	GasUnitPrice   int64  `json:"gasUnitPrice"`
+	// GasCost is GasUsed * GasUnitPrice in octas.
+	GasCost   int64     `json:"gasCost"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
@@ -0,0 +1,94 @@
+package aptos
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// ed25519Scheme is the authentication key scheme byte for single ed25519 keys.
+const ed25519Scheme = 0x00
+
+type AccountAddress [32]byte
+
+// ParseAddress accepts both long and short (leading zeros trimmed) hex forms,
+// with or without the 0x prefix.
+func ParseAddress(s string) (AccountAddress, error) {
+	var a AccountAddress
+	s = strings.TrimPrefix(strings.TrimSpace(s), "0x")
+	if len(s) == 0 || len(s) > 64 {
+		return a, fmt.Errorf("invalid address %q", s)
+	}
+	if len(s)%2 == 1 {
+		s = "0" + s
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return a, fmt.Errorf("invalid address %q: %w", s, err)
+	}
+	copy(a[32-len(b):], b)
+	return a, nil
+}
+
+func (a AccountAddress) String() string {
+	return "0x" + hex.EncodeToString(a[:])
+}
+
+// SameAddress compares two hex addresses ignoring case and leading zeros.
+func SameAddress(a, b string) bool {
+	addrA, err := ParseAddress(a)
+	if err != nil {
+		return false
+	}
+	addrB, err := ParseAddress(b)
+	if err != nil {
+		return false
+	}
+	return addrA == addrB
+}
+
+// Account is an ed25519 key pair able to sign transactions.
+type Account struct {
+	Address    AccountAddress
+	PrivateKey ed25519.PrivateKey
+}
+
+// NewAccountFromHex builds an account from a hex encoded 32 byte ed25519 seed.
+// The AIP-80 "ed25519-priv-" prefix is accepted as well.
+func NewAccountFromHex(key string) (*Account, error) {
+	key = strings.TrimPrefix(strings.TrimSpace(key), "ed25519-priv-")
+	seed, err := hex.DecodeString(strings.TrimPrefix(key, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("invalid private key length %d", len(seed))
+	}
+	priv := ed25519.NewKeyFromSeed(seed)
+	return &Account{
+		Address:    AuthKey(priv.Public().(ed25519.PublicKey)),
+		PrivateKey: priv,
+	}, nil
+}
+
+func (a *Account) PublicKey() ed25519.PublicKey {
+	return a.PrivateKey.Public().(ed25519.PublicKey)
+}
+
+func (a *Account) Sign(msg []byte) []byte {
+	return ed25519.Sign(a.PrivateKey, msg)
+}
+
+// AuthKey derives the authentication key, which is also the default account
+// address, of a single ed25519 public key.
+func AuthKey(pub ed25519.PublicKey) AccountAddress {
+	h := sha3.New256()
+	h.Write(pub)
+	h.Write([]byte{ed25519Scheme})
+	var a AccountAddress
+	copy(a[:], h.Sum(nil))
+	return a
+}
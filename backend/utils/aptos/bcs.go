@@ -0,0 +1,96 @@
+package aptos
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// Serializer writes values using the Binary Canonical Serialization (BCS)
+// format expected by the Aptos VM.
+type Serializer struct {
+	buf bytes.Buffer
+}
+
+func (s *Serializer) Bytes() []byte {
+	return s.buf.Bytes()
+}
+
+func (s *Serializer) Uleb128(v uint32) {
+	for v >= 0x80 {
+		s.buf.WriteByte(byte(v&0x7f) | 0x80)
+		v >>= 7
+	}
+	s.buf.WriteByte(byte(v))
+}
+
+func (s *Serializer) U8(v uint8) {
+	s.buf.WriteByte(v)
+}
+
+func (s *Serializer) U64(v uint64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	s.buf.Write(b[:])
+}
+
+func (s *Serializer) Bool(v bool) {
+	if v {
+		s.buf.WriteByte(1)
+		return
+	}
+	s.buf.WriteByte(0)
+}
+
+// FixedBytes writes b without a length prefix.
+func (s *Serializer) FixedBytes(b []byte) {
+	s.buf.Write(b)
+}
+
+// WriteBytes writes b prefixed with its uleb128 length.
+func (s *Serializer) WriteBytes(b []byte) {
+	s.Uleb128(uint32(len(b)))
+	s.buf.Write(b)
+}
+
+func (s *Serializer) Str(v string) {
+	s.WriteBytes([]byte(v))
+}
+
+func (s *Serializer) Address(a AccountAddress) {
+	s.buf.Write(a[:])
+}
+
+// The helpers below encode a single entry function argument.
+
+func SerializeU64(v uint64) []byte {
+	s := &Serializer{}
+	s.U64(v)
+	return s.Bytes()
+}
+
+func SerializeBool(v bool) []byte {
+	s := &Serializer{}
+	s.Bool(v)
+	return s.Bytes()
+}
+
+func SerializeString(v string) []byte {
+	s := &Serializer{}
+	s.Str(v)
+	return s.Bytes()
+}
+
+func SerializeAddress(a AccountAddress) []byte {
+	s := &Serializer{}
+	s.Address(a)
+	return s.Bytes()
+}
+
+func SerializeU64Vector(v []uint64) []byte {
+	s := &Serializer{}
+	s.Uleb128(uint32(len(v)))
+	for _, n := range v {
+		s.U64(n)
+	}
+	return s.Bytes()
+}
@@ -0,0 +1,263 @@
+package aptos
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const signedTxContentType = "application/x.aptos.signed_transaction+bcs"
+
+var ErrTransactionNotFound = errors.New("transaction not found")
+
+// Client talks to the REST API of an Aptos fullnode, e.g.
+// https://fullnode.random.aptoslabs.com/v1
+type Client struct {
+	NodeURL      string
+	HTTP         *http.Client
+	PollInterval time.Duration
+
+	gasMu      sync.Mutex
+	gasPrice   uint64
+	gasPriceAt time.Time
+}
+
+func NewClient(nodeURL string) *Client {
+	return &Client{
+		NodeURL:      strings.TrimRight(nodeURL, "/"),
+		HTTP:         &http.Client{Timeout: 30 * time.Second},
+		PollInterval: 500 * time.Millisecond,
+	}
+}
+
+// HTTPError is returned for every non 2xx answer of the node.
+type HTTPError struct {
+	StatusCode  int
+	Message     string `json:"message"`
+	ErrorCode   string `json:"error_code"`
+	VMErrorCode int    `json:"vm_error_code"`
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("aptos node returned %d %s: %s", e.StatusCode, e.ErrorCode, e.Message)
+}
+
+type LedgerInfo struct {
+	ChainID         uint8  `json:"chain_id"`
+	LedgerVersion   string `json:"ledger_version"`
+	LedgerTimestamp string `json:"ledger_timestamp"`
+}
+
+type AccountInfo struct {
+	SequenceNumber    string `json:"sequence_number"`
+	AuthenticationKey string `json:"authentication_key"`
+}
+
+type EventGUID struct {
+	CreationNumber string `json:"creation_number"`
+	AccountAddress string `json:"account_address"`
+}
+
+type Event struct {
+	GUID           EventGUID       `json:"guid"`
+	SequenceNumber string          `json:"sequence_number"`
+	Type           string          `json:"type"`
+	Data           json.RawMessage `json:"data"`
+}
+
+// Transaction is the subset of the node's transaction JSON the backend uses.
+type Transaction struct {
+	Type           string  `json:"type"`
+	Hash           string  `json:"hash"`
+	Version        string  `json:"version"`
+	Sender         string  `json:"sender"`
+	SequenceNumber string  `json:"sequence_number"`
+	GasUsed        string  `json:"gas_used"`
+	GasUnitPrice   string  `json:"gas_unit_price"`
+	Success        bool    `json:"success"`
+	VMStatus       string  `json:"vm_status"`
+	Timestamp      string  `json:"timestamp"`
+	Events         []Event `json:"events"`
+}
+
+func (t *Transaction) Pending() bool {
+	return t.Type == "pending_transaction"
+}
+
+func (c *Client) Info(ctx context.Context) (*LedgerInfo, error) {
+	var info LedgerInfo
+	if err := c.get(ctx, "", &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+func (c *Client) Account(ctx context.Context, addr AccountAddress) (*AccountInfo, error) {
+	var info AccountInfo
+	if err := c.get(ctx, "/accounts/"+addr.String(), &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// AccountResource decodes the data of the resource of type resourceType held
+// by addr into out.
+func (c *Client) AccountResource(ctx context.Context, addr AccountAddress, resourceType string, out interface{}) error {
+	var resource struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := c.get(ctx, "/accounts/"+addr.String()+"/resource/"+url.PathEscape(resourceType), &resource); err != nil {
+		return err
+	}
+	return json.Unmarshal(resource.Data, out)
+}
+
+// View calls a view function and returns its return values. Arguments are
+// passed in their JSON form, u64 values as decimal strings.
+func (c *Client) View(ctx context.Context, function string, typeArgs []string, args ...interface{}) ([]json.RawMessage, error) {
+	if typeArgs == nil {
+		typeArgs = []string{}
+	}
+	if args == nil {
+		args = []interface{}{}
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"function":       function,
+		"type_arguments": typeArgs,
+		"arguments":      args,
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.NodeURL+"/view", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	var values []json.RawMessage
+	if err := c.do(req, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// SubmitTransaction posts a BCS encoded SignedTransaction and returns the
+// pending transaction reported by the node.
+func (c *Client) SubmitTransaction(ctx context.Context, signed []byte) (*Transaction, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.NodeURL+"/transactions", bytes.NewReader(signed))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", signedTxContentType)
+	var tx Transaction
+	if err := c.do(req, &tx); err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
+
+// SimulateTransaction runs a transaction, built with SimulationBytes, without
+// committing it. The node picks the max gas amount from the balance of the
+// sender, so the gas used of the result is the real cost of the call.
+func (c *Client) SimulateTransaction(ctx context.Context, signed []byte) (*Transaction, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		c.NodeURL+"/transactions/simulate?estimate_max_gas_amount=true", bytes.NewReader(signed))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", signedTxContentType)
+	var txs []Transaction
+	if err := c.do(req, &txs); err != nil {
+		return nil, err
+	}
+	if len(txs) != 1 {
+		return nil, fmt.Errorf("simulation returned %d transactions", len(txs))
+	}
+	return &txs[0], nil
+}
+
+// GasEstimate is the gas unit price the node suggests, in octas.
+type GasEstimate struct {
+	Deprioritized uint64 `json:"deprioritized_gas_estimate"`
+	Estimate      uint64 `json:"gas_estimate"`
+	Prioritized   uint64 `json:"prioritized_gas_estimate"`
+}
+
+func (c *Client) EstimateGasPrice(ctx context.Context) (*GasEstimate, error) {
+	var estimate GasEstimate
+	if err := c.get(ctx, "/estimate_gas_price", &estimate); err != nil {
+		return nil, err
+	}
+	return &estimate, nil
+}
+
+func (c *Client) TransactionByHash(ctx context.Context, hash string) (*Transaction, error) {
+	var tx Transaction
+	if err := c.get(ctx, "/transactions/by_hash/"+hash, &tx); err != nil {
+		var httpErr *HTTPError
+		if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("%w: %s", ErrTransactionNotFound, hash)
+		}
+		return nil, err
+	}
+	return &tx, nil
+}
+
+// WaitForTransaction polls the node until the transaction is committed or ctx
+// is done. A committed but failed transaction is returned without error,
+// callers have to check Success.
+func (c *Client) WaitForTransaction(ctx context.Context, hash string) (*Transaction, error) {
+	ticker := time.NewTicker(c.PollInterval)
+	defer ticker.Stop()
+	for {
+		tx, err := c.TransactionByHash(ctx, hash)
+		if err != nil && !errors.Is(err, ErrTransactionNotFound) {
+			return nil, err
+		}
+		if err == nil && !tx.Pending() {
+			return tx, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("waiting for transaction %s: %w", hash, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Client) get(ctx context.Context, path string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.NodeURL+path, nil)
+	if err != nil {
+		return err
+	}
+	return c.do(req, out)
+}
+
+func (c *Client) do(req *http.Request, out interface{}) error {
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		httpErr := &HTTPError{StatusCode: resp.StatusCode}
+		if json.Unmarshal(body, httpErr) != nil || httpErr.Message == "" {
+			httpErr.Message = string(body)
+		}
+		return httpErr
+	}
+	return json.Unmarshal(body, out)
+}
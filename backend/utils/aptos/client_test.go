@@ -0,0 +1,326 @@
+package aptos_test
+
+import (
+	"VirtueGaming/utils/aptos"
+	"VirtueGaming/utils/smartcontract"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testKey is the ed25519 test account of the Aptos TypeScript SDK.
+const (
+	testKey     = "ed25519-priv-0xc5338cd251c22daa8c9c9cc94f498cc8a5c7e1d2e75287a5dda91096fe64efa5"
+	testPubKey  = "de19e5d1880cac87d57484ce9ed2e84cf0f9599f12e7cc3a52e4e7657a763f2c"
+	testAddress = "0x978c213990c4833df71548df7ce49d54c759d6b6d932de22b24d56060b7af2aa"
+)
+
+// The sha3-256 hashes of the signing domains.
+const (
+	rawTransactionPrefix         = "b5e97db07fa0bd0e5598aa3643a9bc6f6693bddc1a9fec9e674a461eaa00b193"
+	rawTransactionWithDataPrefix = "5efa3c4f02f83a0f4b2d69fc95c607cc02825cc4e7be536ef0992df050d9e67c"
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(strings.Join(strings.Fields(s), ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func mustAddress(t *testing.T, s string) aptos.AccountAddress {
+	t.Helper()
+	a, err := aptos.ParseAddress(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func TestSerializeArguments(t *testing.T) {
+	uleb := func(v uint32) []byte {
+		s := &aptos.Serializer{}
+		s.Uleb128(v)
+		return s.Bytes()
+	}
+	tests := []struct {
+		name string
+		got  []byte
+		want string
+	}{
+		{"uleb128 0", uleb(0), "00"},
+		{"uleb128 127", uleb(127), "7f"},
+		{"uleb128 128", uleb(128), "8001"},
+		{"uleb128 16384", uleb(16384), "808001"},
+		{"u64", aptos.SerializeU64(1), "0100000000000000"},
+		{"u64 max", aptos.SerializeU64(^uint64(0)), "ffffffffffffffff"},
+		{"bool", aptos.SerializeBool(true), "01"},
+		{"string", aptos.SerializeString("abc"), "03616263"},
+		{"empty string", aptos.SerializeString(""), "00"},
+		{"address", aptos.SerializeAddress(mustAddress(t, "0x1")), strings.Repeat("00", 31) + "01"},
+		{"u64 vector", aptos.SerializeU64Vector([]uint64{1, 2}), "02 0100000000000000 0200000000000000"},
+	}
+	for _, tt := range tests {
+		if want := mustHex(t, tt.want); !bytes.Equal(tt.got, want) {
+			t.Errorf("%s: got %x, want %x", tt.name, tt.got, want)
+		}
+	}
+}
+
+func TestAccountFromHex(t *testing.T) {
+	a, err := aptos.NewAccountFromHex(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hex.EncodeToString(a.PublicKey()); got != testPubKey {
+		t.Errorf("public key %s, want %s", got, testPubKey)
+	}
+	if got := a.Address.String(); got != testAddress {
+		t.Errorf("address %s, want %s", got, testAddress)
+	}
+}
+
+// testTransaction is 0x1::coin::transfer of 100 octas to 0x2, with the BCS
+// encoding written out field by field.
+func testTransaction(t *testing.T) (*aptos.RawTransaction, []byte) {
+	payload, err := aptos.ParseEntryFunction("0x1::aptos_account::transfer",
+		aptos.SerializeAddress(mustAddress(t, "0x2")), aptos.SerializeU64(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw := &aptos.RawTransaction{
+		Sender:                  mustAddress(t, "0x1"),
+		SequenceNumber:          7,
+		Payload:                 payload,
+		MaxGasAmount:            2000,
+		GasUnitPrice:            100,
+		ExpirationTimestampSecs: 1700000000,
+		ChainID:                 4,
+	}
+	want := mustHex(t, strings.Repeat("00", 31)+"01"+ // sender
+		"0700000000000000"+ // sequence number
+		"02"+ // entry function payload
+		strings.Repeat("00", 31)+"01"+ // module address
+		"0d"+hex.EncodeToString([]byte("aptos_account"))+
+		"08"+hex.EncodeToString([]byte("transfer"))+
+		"00"+ // type arguments
+		"02"+ // arguments
+		"20"+strings.Repeat("00", 31)+"02"+
+		"08"+"6400000000000000"+
+		"d007000000000000"+ // max gas amount
+		"6400000000000000"+ // gas unit price
+		"00f1536500000000"+ // expiration
+		"04") // chain id
+	return raw, want
+}
+
+func TestRawTransactionBCS(t *testing.T) {
+	raw, want := testTransaction(t)
+	if got := raw.MarshalBCS(); !bytes.Equal(got, want) {
+		t.Errorf("got %x\nwant %x", got, want)
+	}
+}
+
+func TestSigningMessage(t *testing.T) {
+	raw, encoded := testTransaction(t)
+	account, err := aptos.NewAccountFromHex(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := append(mustHex(t, rawTransactionPrefix), encoded...)
+	if got := raw.SigningMessage(); !bytes.Equal(got, want) {
+		t.Errorf("raw transaction: got %x, want %x", got, want)
+	}
+	sender := aptos.NewSenderTransaction(raw)
+	if got := sender.SigningMessage(); !bytes.Equal(got, want) {
+		t.Errorf("sender transaction: got %x, want %x", got, want)
+	}
+	sig := account.Sign(sender.SigningMessage())
+	if !sender.VerifySender(account.PublicKey(), sig) {
+		t.Error("sender signature rejected")
+	}
+	signed := sender.Sign(account.PublicKey(), sig)
+	wantSigned := append(append(append(encoded, 0x00, 0x20), account.PublicKey()...), append([]byte{0x40}, sig...)...)
+	if !bytes.Equal(signed, wantSigned) {
+		t.Errorf("signed transaction: got %x, want %x", signed, wantSigned)
+	}
+
+	feePayer := mustAddress(t, "0x3")
+	tx := aptos.NewFeePayerTransaction(raw, feePayer)
+	wantFeePayer := mustHex(t, rawTransactionWithDataPrefix+"01"+hex.EncodeToString(encoded)+"00"+strings.Repeat("00", 31)+"03")
+	if got := tx.SigningMessage(); !bytes.Equal(got, wantFeePayer) {
+		t.Errorf("fee payer transaction: got %x, want %x", got, wantFeePayer)
+	}
+	if !tx.VerifySender(account.PublicKey(), account.Sign(tx.SigningMessage())) {
+		t.Error("sender signature of the fee payer transaction rejected")
+	}
+	// Wallets that do not know the fee payer sign with the zero address.
+	unknown := mustHex(t, rawTransactionWithDataPrefix+"01"+hex.EncodeToString(encoded)+"00"+strings.Repeat("00", 32))
+	if !tx.VerifySender(account.PublicKey(), account.Sign(unknown)) {
+		t.Error("sender signature without fee payer rejected")
+	}
+	if tx.VerifySender(account.PublicKey(), account.Sign(want)) {
+		t.Error("signature of the raw transaction accepted for the fee payer transaction")
+	}
+}
+
+func TestSubmitTransaction(t *testing.T) {
+	signed := []byte{1, 2, 3}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/transactions" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/x.aptos.signed_transaction+bcs" {
+			t.Errorf("content type %q", ct)
+		}
+		body, _ := io.ReadAll(r.Body)
+		if !bytes.Equal(body, signed) {
+			t.Errorf("body %x, want %x", body, signed)
+		}
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]string{"type": "pending_transaction", "hash": "0xabc"})
+	}))
+	defer srv.Close()
+
+	tx, err := aptos.NewClient(srv.URL).SubmitTransaction(context.Background(), signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.Hash != "0xabc" || !tx.Pending() {
+		t.Errorf("got %+v", tx)
+	}
+}
+
+func TestSubmitTransactionRejected(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"message":"Invalid transaction: Type: Validation Code: SEQUENCE_NUMBER_TOO_OLD","error_code":"vm_error","vm_error_code":3}`))
+	}))
+	defer srv.Close()
+
+	_, err := aptos.NewClient(srv.URL).SubmitTransaction(context.Background(), []byte{1})
+	var httpErr *aptos.HTTPError
+	if !errors.As(err, &httpErr) {
+		t.Fatalf("got %v, want an HTTPError", err)
+	}
+	if httpErr.StatusCode != http.StatusBadRequest || httpErr.ErrorCode != "vm_error" || httpErr.VMErrorCode != 3 {
+		t.Errorf("got %+v", httpErr)
+	}
+}
+
+// nodeServer answers /transactions/by_hash with the transactions of answers in
+// turn, a nil answer being a 404, and repeats the last one.
+func nodeServer(t *testing.T, answers ...*aptos.Transaction) (*httptest.Server, *int32) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/transactions/by_hash/0xabc" {
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+		n := int(atomic.AddInt32(&calls, 1)) - 1
+		if n >= len(answers) {
+			n = len(answers) - 1
+		}
+		if answers[n] == nil {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"Transaction not found by Transaction hash(0xabc)","error_code":"transaction_not_found"}`))
+			return
+		}
+		json.NewEncoder(w).Encode(answers[n])
+	}))
+	return srv, &calls
+}
+
+func TestWaitForTransaction(t *testing.T) {
+	srv, calls := nodeServer(t,
+		nil,
+		&aptos.Transaction{Type: "pending_transaction", Hash: "0xabc"},
+		&aptos.Transaction{Type: "user_transaction", Hash: "0xabc", Version: "42", Success: true, VMStatus: "Executed successfully"},
+	)
+	defer srv.Close()
+	client := aptos.NewClient(srv.URL)
+	client.PollInterval = time.Millisecond
+
+	tx, err := client.WaitForTransaction(context.Background(), "0xabc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tx.Success || tx.Version != "42" {
+		t.Errorf("got %+v", tx)
+	}
+	if n := atomic.LoadInt32(calls); n != 3 {
+		t.Errorf("polled %d times, want 3", n)
+	}
+}
+
+func TestWaitForTransactionTimeout(t *testing.T) {
+	srv, _ := nodeServer(t, &aptos.Transaction{Type: "pending_transaction", Hash: "0xabc"})
+	defer srv.Close()
+	client := aptos.NewClient(srv.URL)
+	client.PollInterval = time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := client.WaitForTransaction(ctx, "0xabc"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want a deadline error", err)
+	}
+}
+
+func TestMoveAbort(t *testing.T) {
+	tests := []struct {
+		vmStatus string
+		want     error
+		code     int
+	}{
+		{"Move abort in 0x1::bingov2: ERROR_GAME_HAS_STARTED(0x4): ", smartcontract.ErrGameHasStarted, 4},
+		// Without the name, the code is looked up in the constants of the module.
+		{"Move abort in 0x1::bingov2: 0x9", smartcontract.ErrNotWinningTicket, 9},
+		{"Move abort in 0x1::SNL: ERROR_NOT_AVATAR_OWNER(0xb): ", smartcontract.ErrNotAvatarOwner, 11},
+	}
+	for _, tt := range tests {
+		srv, _ := nodeServer(t, &aptos.Transaction{Type: "user_transaction", Hash: "0xabc", Success: false, VMStatus: tt.vmStatus})
+		tx, err := aptos.NewClient(srv.URL).WaitForTransaction(context.Background(), "0xabc")
+		srv.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tx.Success {
+			t.Fatalf("%q: transaction reported as successful", tt.vmStatus)
+		}
+		abort := smartcontract.ParseAbort(tx.VMStatus)
+		if abort == nil {
+			t.Fatalf("%q: not decoded as a Move abort", tt.vmStatus)
+		}
+		if !errors.Is(abort, tt.want) || abort.Code != tt.code {
+			t.Errorf("%q: got %v (code %d), want %v (code %d)", tt.vmStatus, abort, abort.Code, tt.want, tt.code)
+		}
+	}
+	if abort := smartcontract.ParseAbort("Out of gas"); abort != nil {
+		t.Errorf("out of gas decoded as %v", abort)
+	}
+}
+
+func TestVerifySenderRejectsOtherKey(t *testing.T) {
+	raw, _ := testTransaction(t)
+	tx := aptos.NewSenderTransaction(raw)
+	account, err := aptos.NewAccountFromHex(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+	if tx.VerifySender(other.Public().(ed25519.PublicKey), account.Sign(tx.SigningMessage())) {
+		t.Error("signature accepted for another key")
+	}
+}
@@ -0,0 +1,63 @@
+package aptos
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// OctasPerAPT is the number of octas, the smallest unit of AptosCoin, in one
+// APT.
+const OctasPerAPT = 100000000
+
+const aptDecimals = 8
+
+// ParseAmount parses an amount of AptosCoin into octas. The amount is either
+// in APT with up to 8 decimals ("1.5 APT") or in octas ("150000000" or
+// "150000000 octas").
+func ParseAmount(s string) (uint64, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 || len(fields) > 2 {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	unit := "octas"
+	if len(fields) == 2 {
+		unit = strings.ToLower(fields[1])
+	}
+	switch unit {
+	case "octas", "octa":
+		octas, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid amount %q: %w", s, err)
+		}
+		return octas, nil
+	case "apt":
+		return parseAPT(fields[0])
+	default:
+		return 0, fmt.Errorf("invalid amount %q: unknown unit %q", s, fields[1])
+	}
+}
+
+func parseAPT(s string) (uint64, error) {
+	whole, frac, _ := strings.Cut(s, ".")
+	if len(frac) > aptDecimals {
+		return 0, fmt.Errorf("invalid amount %q: more than %d decimals", s, aptDecimals)
+	}
+	frac += strings.Repeat("0", aptDecimals-len(frac))
+	if whole == "" {
+		whole = "0"
+	}
+	w, err := strconv.ParseUint(whole, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q: %w", s, err)
+	}
+	f, err := strconv.ParseUint(frac, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q: %w", s, err)
+	}
+	if w > (math.MaxUint64-f)/OctasPerAPT {
+		return 0, fmt.Errorf("invalid amount %q: too large", s)
+	}
+	return w*OctasPerAPT + f, nil
+}
@@ -0,0 +1,133 @@
+package aptos
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"net/http"
+	"time"
+)
+
+const (
+	rawTransactionWithFeePayer  = 1
+	authenticatorFeePayer       = 3
+	accountAuthenticatorEd25519 = 0
+
+	// walletExpiration leaves the player time to approve a transaction in
+	// their wallet.
+	walletExpiration = 2 * time.Minute
+)
+
+// FeePayerTransaction is a transaction whose gas is paid by FeePayer instead
+// of by its sender. Both sign the same message: the sender in its wallet, the
+// fee payer in the backend. Raw is kept BCS encoded, so the transaction the
+// sender signed can be stored and sent later as is.
+type FeePayerTransaction struct {
+	Raw      []byte
+	FeePayer AccountAddress
+}
+
+func NewFeePayerTransaction(raw *RawTransaction, feePayer AccountAddress) *FeePayerTransaction {
+	return &FeePayerTransaction{Raw: raw.MarshalBCS(), FeePayer: feePayer}
+}
+
+// SigningMessage is the message sender and fee payer sign.
+func (t *FeePayerTransaction) SigningMessage() []byte {
+	return t.signingMessage(t.FeePayer)
+}
+
+// signingMessage serializes the RawTransactionWithData::MultiAgentWithFeePayer
+// variant, without secondary signers.
+func (t *FeePayerTransaction) signingMessage(feePayer AccountAddress) []byte {
+	s := &Serializer{}
+	s.Uleb128(rawTransactionWithFeePayer)
+	s.FixedBytes(t.Raw)
+	s.Uleb128(0)
+	s.Address(feePayer)
+	return append(prefixHash("APTOS::RawTransactionWithData"), s.Bytes()...)
+}
+
+// VerifySender checks the signature of the sender. Wallets that did not know
+// the fee payer when signing sign with the zero address instead, which the
+// node accepts as well.
+func (t *FeePayerTransaction) VerifySender(pub ed25519.PublicKey, sig []byte) bool {
+	if len(pub) != ed25519.PublicKeySize {
+		return false
+	}
+	return ed25519.Verify(pub, t.SigningMessage(), sig) ||
+		ed25519.Verify(pub, t.signingMessage(AccountAddress{}), sig)
+}
+
+// Sign returns the BCS encoded SignedTransaction, carrying the signature of
+// the sender and the one of feePayer.
+func (t *FeePayerTransaction) Sign(senderPub ed25519.PublicKey, senderSig []byte, feePayer *Account) []byte {
+	return t.signed(senderPub, senderSig, feePayer.PublicKey(), feePayer.Sign(t.SigningMessage()))
+}
+
+// SimulationBytes is the SignedTransaction for the simulation endpoint, see
+// RawTransaction.SimulationBytes.
+func (t *FeePayerTransaction) SimulationBytes(senderPub, feePayerPub ed25519.PublicKey) []byte {
+	zero := make([]byte, ed25519.SignatureSize)
+	return t.signed(senderPub, zero, feePayerPub, zero)
+}
+
+func (t *FeePayerTransaction) signed(senderPub ed25519.PublicKey, senderSig []byte, feePayerPub ed25519.PublicKey, feePayerSig []byte) []byte {
+	s := &Serializer{}
+	s.FixedBytes(t.Raw)
+	s.Uleb128(authenticatorFeePayer)
+	s.Uleb128(accountAuthenticatorEd25519)
+	s.WriteBytes(senderPub)
+	s.WriteBytes(senderSig)
+	// no secondary signers
+	s.Uleb128(0)
+	s.Uleb128(0)
+	s.Address(t.FeePayer)
+	s.Uleb128(accountAuthenticatorEd25519)
+	s.WriteBytes(feePayerPub)
+	s.WriteBytes(feePayerSig)
+	return s.Bytes()
+}
+
+// BuildFeePayerTransaction builds payload as sent by sender with the gas paid
+// by feePayer, and sizes its gas like EstimateGas. The sender does not need to
+// exist on chain yet: the node creates its account with the transaction. The
+// caller has to check Success of the returned simulation.
+func (c *Client) BuildFeePayerTransaction(ctx context.Context, sender ed25519.PublicKey, feePayer *Account, payload EntryFunction) (*FeePayerTransaction, *RawTransaction, *Transaction, error) {
+	raw, err := c.buildWalletTransaction(ctx, sender, payload)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	simulated := NewFeePayerTransaction(raw, feePayer.Address)
+	sim, err := c.SimulateTransaction(ctx, simulated.SimulationBytes(sender, feePayer.PublicKey()))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	raw.MaxGasAmount = withMargin(sim.GasUsed)
+	return NewFeePayerTransaction(raw, feePayer.Address), raw, sim, nil
+}
+
+// buildWalletTransaction builds payload as sent by the account of sender at
+// the current gas price, to be signed in a wallet before walletExpiration.
+func (c *Client) buildWalletTransaction(ctx context.Context, sender ed25519.PublicKey, payload EntryFunction) (*RawTransaction, error) {
+	price, err := c.GasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	info, err := c.Info(ctx)
+	if err != nil {
+		return nil, err
+	}
+	addr := AuthKey(sender)
+	seq, err := c.SequenceNumber(ctx, addr)
+	if err != nil && !accountNotFound(err) {
+		return nil, err
+	}
+	raw := newRawTransaction(addr, seq, info.ChainID, payload, GasOptions{GasUnitPrice: price})
+	raw.ExpirationTimestampSecs = uint64(time.Now().Add(walletExpiration).Unix())
+	return raw, nil
+}
+
+func accountNotFound(err error) bool {
+	var httpErr *HTTPError
+	return errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound
+}
@@ -0,0 +1,64 @@
+package aptos
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+const (
+	// gasPriceTTL is how long a gas price estimate of the node is reused.
+	gasPriceTTL = 30 * time.Second
+	// gasMargin is added to the gas used by a simulation, in percent, since
+	// the state may change between simulating and committing.
+	gasMargin = 50
+	// minGasAmount is the lowest max gas amount a transaction is sent with.
+	minGasAmount = 2000
+)
+
+// GasPrice returns the gas unit price the node currently suggests. The
+// estimate is cached for gasPriceTTL.
+func (c *Client) GasPrice(ctx context.Context) (uint64, error) {
+	c.gasMu.Lock()
+	defer c.gasMu.Unlock()
+	if c.gasPrice != 0 && time.Since(c.gasPriceAt) < gasPriceTTL {
+		return c.gasPrice, nil
+	}
+	estimate, err := c.EstimateGasPrice(ctx)
+	if err != nil {
+		return 0, err
+	}
+	c.gasPrice, c.gasPriceAt = estimate.Estimate, time.Now()
+	return c.gasPrice, nil
+}
+
+// EstimateGas simulates payload sent by signer. It returns the gas options to
+// submit the call with, the max gas amount being the gas used by the
+// simulation plus gasMargin, and the simulated transaction. The caller has to
+// check Success of the simulation: a call that aborts is not worth sending.
+func (c *Client) EstimateGas(ctx context.Context, signer *Account, payload EntryFunction) (GasOptions, *Transaction, error) {
+	price, err := c.GasPrice(ctx)
+	if err != nil {
+		return GasOptions{}, nil, err
+	}
+	raw, err := c.BuildTransaction(ctx, signer.Address, payload, GasOptions{GasUnitPrice: price})
+	if err != nil {
+		return GasOptions{}, nil, err
+	}
+	sim, err := c.SimulateTransaction(ctx, raw.SimulationBytes(signer.PublicKey()))
+	if err != nil {
+		return GasOptions{}, nil, err
+	}
+	return GasOptions{MaxGasAmount: withMargin(sim.GasUsed), GasUnitPrice: price}, sim, nil
+}
+
+// withMargin is the max gas amount for a call that used gasUsed in its
+// simulation.
+func withMargin(gasUsed string) uint64 {
+	used, _ := strconv.ParseUint(gasUsed, 10, 64)
+	max := used * (100 + gasMargin) / 100
+	if max < minGasAmount {
+		max = minGasAmount
+	}
+	return max
+}
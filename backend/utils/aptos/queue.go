@@ -0,0 +1,114 @@
+package aptos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// maxResubmits bounds how often Submit retries a transaction whose sequence
+// number turned out to be stale.
+const maxResubmits = 3
+
+// VM error codes of the sequence number checks of the mempool.
+const (
+	vmSequenceNumberTooOld = 3
+	vmSequenceNumberTooNew = 4
+)
+
+// SignerQueue submits the transactions of one account one after the other.
+// It hands out sequence numbers locally, so concurrent callers do not race on
+// the number stored on chain, and only goes back to the node when a number
+// turns out to be stale.
+type SignerQueue struct {
+	client *Client
+	signer *Account
+
+	mu      sync.Mutex
+	synced  bool
+	next    uint64
+	chainID uint8
+
+	depth int64
+}
+
+func NewSignerQueue(client *Client, signer *Account) *SignerQueue {
+	return &SignerQueue{client: client, signer: signer}
+}
+
+func (q *SignerQueue) Signer() *Account {
+	return q.signer
+}
+
+// Depth is the number of submissions waiting for or being submitted.
+func (q *SignerQueue) Depth() int {
+	return int(atomic.LoadInt64(&q.depth))
+}
+
+// Reset drops the local sequence number, the next submission reads it from
+// the node again. Call it when a submitted transaction was not committed, the
+// numbers handed out after it would stay stuck behind the gap otherwise.
+func (q *SignerQueue) Reset() {
+	q.mu.Lock()
+	q.synced = false
+	q.mu.Unlock()
+}
+
+// Submit signs and submits payload with the next sequence number and returns
+// the pending transaction. It does not wait for the transaction to be
+// committed, so the next submission can go out right away.
+func (q *SignerQueue) Submit(ctx context.Context, payload EntryFunction, gas GasOptions) (*Transaction, error) {
+	atomic.AddInt64(&q.depth, 1)
+	defer atomic.AddInt64(&q.depth, -1)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for attempt := 0; ; attempt++ {
+		if !q.synced {
+			if err := q.sync(ctx); err != nil {
+				return nil, err
+			}
+		}
+		raw := newRawTransaction(q.signer.Address, q.next, q.chainID, payload, gas)
+		tx, err := q.client.SubmitTransaction(ctx, raw.Sign(q.signer))
+		if err == nil {
+			q.next++
+			return tx, nil
+		}
+		if !staleSequenceNumber(err) || attempt == maxResubmits {
+			return nil, err
+		}
+		q.synced = false
+	}
+}
+
+func (q *SignerQueue) sync(ctx context.Context) error {
+	info, err := q.client.Info(ctx)
+	if err != nil {
+		return err
+	}
+	seq, err := q.client.SequenceNumber(ctx, q.signer.Address)
+	if err != nil {
+		return fmt.Errorf("sequence number of %s: %w", q.signer.Address, err)
+	}
+	q.chainID = info.ChainID
+	q.next = seq
+	q.synced = true
+	return nil
+}
+
+// staleSequenceNumber reports whether the node rejected a transaction because
+// its sequence number no longer matches the account.
+func staleSequenceNumber(err error) bool {
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) {
+		return false
+	}
+	return httpErr.ErrorCode == "sequence_number_too_old" ||
+		httpErr.VMErrorCode == vmSequenceNumberTooOld ||
+		httpErr.VMErrorCode == vmSequenceNumberTooNew ||
+		strings.Contains(httpErr.Message, "SEQUENCE_NUMBER_TOO_OLD")
+}
@@ -0,0 +1,54 @@
+package aptos
+
+import (
+	"context"
+	"crypto/ed25519"
+)
+
+// SenderTransaction is a transaction signed and paid for by its sender alone,
+// built by the backend and signed in the sender's wallet. Like
+// FeePayerTransaction, Raw is kept BCS encoded until the signature comes back.
+type SenderTransaction struct {
+	Raw []byte
+}
+
+func NewSenderTransaction(raw *RawTransaction) *SenderTransaction {
+	return &SenderTransaction{Raw: raw.MarshalBCS()}
+}
+
+// SigningMessage is the message the sender signs.
+func (t *SenderTransaction) SigningMessage() []byte {
+	return append(prefixHash("APTOS::RawTransaction"), t.Raw...)
+}
+
+// VerifySender checks the signature of the sender.
+func (t *SenderTransaction) VerifySender(pub ed25519.PublicKey, sig []byte) bool {
+	return len(pub) == ed25519.PublicKeySize && ed25519.Verify(pub, t.SigningMessage(), sig)
+}
+
+// Sign returns the BCS encoded SignedTransaction carrying the signature of
+// the sender.
+func (t *SenderTransaction) Sign(senderPub ed25519.PublicKey, senderSig []byte) []byte {
+	s := &Serializer{}
+	s.FixedBytes(t.Raw)
+	s.Uleb128(authenticatorEd25519)
+	s.WriteBytes(senderPub)
+	s.WriteBytes(senderSig)
+	return s.Bytes()
+}
+
+// BuildSenderTransaction builds payload as sent by sender, who pays its gas,
+// and sizes its gas like EstimateGas. The caller has to check Success of the
+// returned simulation.
+func (c *Client) BuildSenderTransaction(ctx context.Context, sender ed25519.PublicKey, payload EntryFunction) (*SenderTransaction, *RawTransaction, *Transaction, error) {
+	raw, err := c.buildWalletTransaction(ctx, sender, payload)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	sim, err := c.SimulateTransaction(ctx, raw.SimulationBytes(sender))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	raw.MaxGasAmount = withMargin(sim.GasUsed)
+	return NewSenderTransaction(raw), raw, sim, nil
+}
@@ -0,0 +1,64 @@
+package aptos
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+const defaultExpiration = 60 * time.Second
+
+type GasOptions struct {
+	MaxGasAmount uint64
+	GasUnitPrice uint64
+}
+
+// BuildTransaction fills sequence number, chain id and expiration for a call
+// sent by sender.
+func (c *Client) BuildTransaction(ctx context.Context, sender AccountAddress, payload EntryFunction, gas GasOptions) (*RawTransaction, error) {
+	info, err := c.Info(ctx)
+	if err != nil {
+		return nil, err
+	}
+	seq, err := c.SequenceNumber(ctx, sender)
+	if err != nil {
+		return nil, err
+	}
+	return newRawTransaction(sender, seq, info.ChainID, payload, gas), nil
+}
+
+// SequenceNumber returns the sequence number the next transaction of addr
+// has to use.
+func (c *Client) SequenceNumber(ctx context.Context, addr AccountAddress) (uint64, error) {
+	account, err := c.Account(ctx, addr)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(account.SequenceNumber, 10, 64)
+}
+
+func newRawTransaction(sender AccountAddress, seq uint64, chainID uint8, payload EntryFunction, gas GasOptions) *RawTransaction {
+	return &RawTransaction{
+		Sender:                  sender,
+		SequenceNumber:          seq,
+		Payload:                 payload,
+		MaxGasAmount:            gas.MaxGasAmount,
+		GasUnitPrice:            gas.GasUnitPrice,
+		ExpirationTimestampSecs: uint64(time.Now().Add(defaultExpiration).Unix()),
+		ChainID:                 chainID,
+	}
+}
+
+// SubmitAndWait builds, signs and submits payload from signer, then waits for
+// the transaction to be committed.
+func (c *Client) SubmitAndWait(ctx context.Context, signer *Account, payload EntryFunction, gas GasOptions) (*Transaction, error) {
+	raw, err := c.BuildTransaction(ctx, signer.Address, payload, gas)
+	if err != nil {
+		return nil, err
+	}
+	pending, err := c.SubmitTransaction(ctx, raw.Sign(signer))
+	if err != nil {
+		return nil, err
+	}
+	return c.WaitForTransaction(ctx, pending.Hash)
+}
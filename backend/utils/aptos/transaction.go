@@ -0,0 +1,115 @@
+package aptos
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+const (
+	payloadEntryFunction = 2
+	authenticatorEd25519 = 0
+)
+
+// EntryFunction is the payload of a call to a `public entry fun`.
+type EntryFunction struct {
+	ModuleAddress AccountAddress
+	ModuleName    string
+	Function      string
+	// Args holds every argument already BCS encoded, see SerializeU64 and friends.
+	Args [][]byte
+}
+
+// ParseEntryFunction splits a "<address>::<module>::<function>" identifier.
+func ParseEntryFunction(id string, args ...[]byte) (EntryFunction, error) {
+	parts := strings.Split(id, "::")
+	if len(parts) != 3 {
+		return EntryFunction{}, fmt.Errorf("invalid function id %q", id)
+	}
+	addr, err := ParseAddress(parts[0])
+	if err != nil {
+		return EntryFunction{}, err
+	}
+	return EntryFunction{
+		ModuleAddress: addr,
+		ModuleName:    parts[1],
+		Function:      parts[2],
+		Args:          args,
+	}, nil
+}
+
+func (f EntryFunction) ID() string {
+	return f.ModuleAddress.String() + "::" + f.ModuleName + "::" + f.Function
+}
+
+func (f EntryFunction) serialize(s *Serializer) {
+	s.Uleb128(payloadEntryFunction)
+	s.Address(f.ModuleAddress)
+	s.Str(f.ModuleName)
+	s.Str(f.Function)
+	// no type arguments are used by the game modules
+	s.Uleb128(0)
+	s.Uleb128(uint32(len(f.Args)))
+	for _, a := range f.Args {
+		s.WriteBytes(a)
+	}
+}
+
+type RawTransaction struct {
+	Sender                  AccountAddress
+	SequenceNumber          uint64
+	Payload                 EntryFunction
+	MaxGasAmount            uint64
+	GasUnitPrice            uint64
+	ExpirationTimestampSecs uint64
+	ChainID                 uint8
+}
+
+func (t *RawTransaction) serialize(s *Serializer) {
+	s.Address(t.Sender)
+	s.U64(t.SequenceNumber)
+	t.Payload.serialize(s)
+	s.U64(t.MaxGasAmount)
+	s.U64(t.GasUnitPrice)
+	s.U64(t.ExpirationTimestampSecs)
+	s.U8(t.ChainID)
+}
+
+func (t *RawTransaction) MarshalBCS() []byte {
+	s := &Serializer{}
+	t.serialize(s)
+	return s.Bytes()
+}
+
+// SigningMessage is the domain separated message the sender signs.
+func (t *RawTransaction) SigningMessage() []byte {
+	return append(prefixHash("APTOS::RawTransaction"), t.MarshalBCS()...)
+}
+
+// Sign returns the BCS encoded SignedTransaction, ready for submission.
+func (t *RawTransaction) Sign(a *Account) []byte {
+	return t.signed(a.PublicKey(), a.Sign(t.SigningMessage()))
+}
+
+// SimulationBytes returns the SignedTransaction the simulation endpoint
+// expects: signed by pub, but with an all zero signature, since the node
+// refuses to simulate validly signed transactions.
+func (t *RawTransaction) SimulationBytes(pub ed25519.PublicKey) []byte {
+	return t.signed(pub, make([]byte, ed25519.SignatureSize))
+}
+
+func (t *RawTransaction) signed(pub ed25519.PublicKey, sig []byte) []byte {
+	s := &Serializer{}
+	t.serialize(s)
+	s.Uleb128(authenticatorEd25519)
+	s.WriteBytes(pub)
+	s.WriteBytes(sig)
+	return s.Bytes()
+}
+
+func prefixHash(domain string) []byte {
+	h := sha3.Sum256([]byte(domain))
+	return h[:]
+}
@@ -0,0 +1,327 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Card is a bingo card of any format, row by row, 0 being an empty cell or
+// the free space.
+type Card [][]int
+
+// Flatten joins the cells of the card row by row, like FlattenTicket.
+func (c Card) Flatten() string {
+	var cells []string
+	for _, row := range c {
+		for _, n := range row {
+			cells = append(cells, strconv.Itoa(n))
+		}
+	}
+	return strings.Join(cells, ",")
+}
+
+// Hash is the canonical hash of the grid: two cards have the same hash when
+// join_game would see them as the same card.
+func (c Card) Hash() string {
+	h := sha256.Sum256([]byte(c.Flatten()))
+	return hex.EncodeToString(h[:])
+}
+
+// CardFormat is a layout of bingo card. The Type of a game selects its format,
+// see FormatForType.
+type CardFormat interface {
+	// Name is the game Type of the format.
+	Name() string
+	// Balls is the highest number of the format. bingov2 draws from 1 to 90
+	// whatever the format: the numbers above Balls are on no card.
+	Balls() int
+	Size() (rows, cols int)
+	// Generate returns the card of seed, always the same for a seed.
+	Generate(seed TicketSeed) Card
+	Validate(card Card) error
+	// Cells is the card as it is rendered, one string per table cell.
+	Cells(card Card) [][]string
+	// ChainRows is the card as join_game stores it: the three rows the prizes
+	// of bingov2 check, see LineDrawn.
+	ChainRows(card Card) Card
+}
+
+var (
+	NinetyBall      CardFormat = ninetyBall{}
+	SeventyFiveBall CardFormat = seventyFiveBall{}
+	ThirtyBall      CardFormat = thirtyBall{}
+)
+
+// FormatForType returns the format of a game Type: "75-ball" or "30-ball".
+// Any other Type, including the ones of the games created before there were
+// formats, is a 90-ball game.
+func FormatForType(gameType string) CardFormat {
+	switch strings.ToLower(strings.TrimSpace(gameType)) {
+	case SeventyFiveBall.Name():
+		return SeventyFiveBall
+	case ThirtyBall.Name():
+		return ThirtyBall
+	default:
+		return NinetyBall
+	}
+}
+
+// ParseCard reads back a card of format f stored with Card.Flatten.
+func ParseCard(f CardFormat, flat string) (Card, error) {
+	rows, cols := f.Size()
+	values := strings.Split(flat, ",")
+	if len(values) != rows*cols {
+		return nil, fmt.Errorf("%s card must have %d cells, got %d", f.Name(), rows*cols, len(values))
+	}
+	card := make(Card, rows)
+	for i, v := range values {
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return nil, fmt.Errorf("invalid card cell %q: %w", v, err)
+		}
+		card[i/cols] = append(card[i/cols], n)
+	}
+	return card, nil
+}
+
+// checkSize checks the dimensions of card and that no number repeats.
+func checkSize(f CardFormat, card Card) error {
+	rows, cols := f.Size()
+	if len(card) != rows {
+		return fmt.Errorf("%s card must have %d rows, got %d", f.Name(), rows, len(card))
+	}
+	seen := make(map[int]bool)
+	for i, row := range card {
+		if len(row) != cols {
+			return fmt.Errorf("row %d must have %d cells, got %d", i, cols, len(row))
+		}
+		for _, n := range row {
+			if n != 0 && seen[n] {
+				return fmt.Errorf("number %d appears twice", n)
+			}
+			seen[n] = true
+		}
+	}
+	return nil
+}
+
+// numberCells renders the numbers of card, leaving the empty cells blank.
+func numberCells(card Card) [][]string {
+	cells := make([][]string, len(card))
+	for i, row := range card {
+		cells[i] = make([]string, len(row))
+		for j, n := range row {
+			if n != 0 {
+				cells[i][j] = strconv.Itoa(n)
+			}
+		}
+	}
+	return cells
+}
+
+// ninetyBall is the UK card: 3 rows of 9 columns, 5 numbers per row, column c
+// holding 1 to 3 numbers from c*10+1 to c*10+10, sorted top to bottom.
+type ninetyBall struct{}
+
+func (ninetyBall) Name() string           { return "90-ball" }
+func (ninetyBall) Balls() int             { return 90 }
+func (ninetyBall) Size() (rows, cols int) { return 3, 9 }
+
+func (ninetyBall) Generate(seed TicketSeed) Card {
+	return TicketCard(GenerateFromSeed(seed))
+}
+
+func (f ninetyBall) Validate(card Card) error {
+	if err := checkSize(f, card); err != nil {
+		return err
+	}
+	for i, row := range card {
+		if count := countNumbers(row); count != 5 {
+			return fmt.Errorf("row %d must have 5 numbers, got %d", i, count)
+		}
+	}
+	for col := 0; col < 9; col++ {
+		count, last := 0, 0
+		for _, row := range card {
+			n := row[col]
+			if n == 0 {
+				continue
+			}
+			if n < col*10+1 || n > col*10+10 {
+				return fmt.Errorf("number %d is out of the range of column %d", n, col)
+			}
+			if n < last {
+				return fmt.Errorf("column %d is not sorted", col)
+			}
+			count, last = count+1, n
+		}
+		if count < 1 || count > 3 {
+			return fmt.Errorf("column %d must have 1 to 3 numbers, got %d", col, count)
+		}
+	}
+	return nil
+}
+
+func (ninetyBall) Cells(card Card) [][]string {
+	return numberCells(card)
+}
+
+// ChainRows is the card itself: 5 numbers and 4 blanks per row.
+func (ninetyBall) ChainRows(card Card) Card {
+	return card
+}
+
+// seventyFiveBall is the US card: B-I-N-G-O columns of 15 numbers each, 5x5
+// with a free space in the centre.
+type seventyFiveBall struct{}
+
+func (seventyFiveBall) Name() string           { return "75-ball" }
+func (seventyFiveBall) Balls() int             { return 75 }
+func (seventyFiveBall) Size() (rows, cols int) { return 5, 5 }
+
+func (seventyFiveBall) Generate(seed TicketSeed) Card {
+	r := newSeedStream(seed)
+	card := emptyCard(5, 5)
+	for col := 0; col < 5; col++ {
+		numbers := r.Perm(15)
+		for row := 0; row < 5; row++ {
+			if row == 2 && col == 2 {
+				continue
+			}
+			card[row][col] = col*15 + numbers[row] + 1
+		}
+	}
+	return card
+}
+
+func (f seventyFiveBall) Validate(card Card) error {
+	if err := checkSize(f, card); err != nil {
+		return err
+	}
+	for row := 0; row < 5; row++ {
+		for col := 0; col < 5; col++ {
+			n := card[row][col]
+			if row == 2 && col == 2 {
+				if n != 0 {
+					return errors.New("the centre must be the free space")
+				}
+				continue
+			}
+			if n < col*15+1 || n > col*15+15 {
+				return fmt.Errorf("number %d is out of the range of column %c", n, "BINGO"[col])
+			}
+		}
+	}
+	return nil
+}
+
+func (seventyFiveBall) Cells(card Card) [][]string {
+	cells := append([][]string{{"B", "I", "N", "G", "O"}}, numberCells(card)...)
+	cells[3][2] = "FREE"
+	return cells
+}
+
+// ChainRows puts the top, centre and bottom lines first in the three rows, so
+// they are the row0, row1 and row2 prizes and the full house is the three of
+// them: check_prize reads 5 numbers per row, a blackout of 24 cannot be
+// checked. The free space holds the first number of the centre line again,
+// which is won once its 4 numbers are drawn.
+// Rows 1 and 3 follow the top and centre lines, past what check_prize reads,
+// so that join_game still tells any two cards apart.
+func (seventyFiveBall) ChainRows(card Card) Card {
+	centre := slices.Clone(card[2])
+	centre[2] = centre[0]
+	return Card{
+		slices.Concat(card[0], card[1]),
+		slices.Concat(centre, card[3]),
+		slices.Clone(card[4]),
+	}
+}
+
+// thirtyBall is the speed bingo card: 3x3, every cell filled, column c holding
+// numbers from c*10+1 to c*10+10 sorted top to bottom.
+type thirtyBall struct{}
+
+func (thirtyBall) Name() string           { return "30-ball" }
+func (thirtyBall) Balls() int             { return 30 }
+func (thirtyBall) Size() (rows, cols int) { return 3, 3 }
+
+func (thirtyBall) Generate(seed TicketSeed) Card {
+	r := newSeedStream(seed)
+	card := emptyCard(3, 3)
+	for col := 0; col < 3; col++ {
+		numbers := r.Perm(10)[:3]
+		slices.Sort(numbers)
+		for row, n := range numbers {
+			card[row][col] = col*10 + n + 1
+		}
+	}
+	return card
+}
+
+func (f thirtyBall) Validate(card Card) error {
+	if err := checkSize(f, card); err != nil {
+		return err
+	}
+	for col := 0; col < 3; col++ {
+		last := 0
+		for _, row := range card {
+			n := row[col]
+			if n < col*10+1 || n > col*10+10 {
+				return fmt.Errorf("number %d is out of the range of column %d", n, col)
+			}
+			if n < last {
+				return fmt.Errorf("column %d is not sorted", col)
+			}
+			last = n
+		}
+	}
+	return nil
+}
+
+func (thirtyBall) Cells(card Card) [][]string {
+	return numberCells(card)
+}
+
+// ChainRows pads every row to the 5 numbers check_prize reads by repeating its
+// first two, so a row wins once its 3 numbers are drawn and the full house is
+// the whole card.
+func (thirtyBall) ChainRows(card Card) Card {
+	rows := make(Card, len(card))
+	for i, row := range card {
+		rows[i] = append(slices.Clone(row), row[0], row[1])
+	}
+	return rows
+}
+
+// TicketCard converts a 90-ball ticket into a Card.
+func TicketCard(ticket [3][9]int) Card {
+	card := make(Card, len(ticket))
+	for i := range ticket {
+		card[i] = append([]int(nil), ticket[i][:]...)
+	}
+	return card
+}
+
+func emptyCard(rows, cols int) Card {
+	card := make(Card, rows)
+	for i := range card {
+		card[i] = make([]int, cols)
+	}
+	return card
+}
+
+func countNumbers(row []int) int {
+	count := 0
+	for _, n := range row {
+		if n != 0 {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,108 @@
+package utils
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestChainRows(t *testing.T) {
+	tests := []struct {
+		format CardFormat
+		card   Card
+		want   Card
+	}{
+		{
+			NinetyBall,
+			Card{
+				{1, 0, 21, 0, 41, 0, 61, 0, 81},
+				{0, 11, 0, 31, 0, 51, 0, 71, 82},
+				{2, 12, 0, 32, 0, 0, 62, 72, 0},
+			},
+			Card{
+				{1, 0, 21, 0, 41, 0, 61, 0, 81},
+				{0, 11, 0, 31, 0, 51, 0, 71, 82},
+				{2, 12, 0, 32, 0, 0, 62, 72, 0},
+			},
+		},
+		{
+			SeventyFiveBall,
+			Card{
+				{1, 16, 31, 46, 61},
+				{2, 17, 32, 47, 62},
+				{3, 18, 0, 48, 63},
+				{4, 19, 34, 49, 64},
+				{5, 20, 35, 50, 65},
+			},
+			Card{
+				{1, 16, 31, 46, 61, 2, 17, 32, 47, 62},
+				{3, 18, 3, 48, 63, 4, 19, 34, 49, 64},
+				{5, 20, 35, 50, 65},
+			},
+		},
+		{
+			ThirtyBall,
+			Card{
+				{1, 11, 21},
+				{2, 12, 22},
+				{3, 13, 23},
+			},
+			Card{
+				{1, 11, 21, 1, 11},
+				{2, 12, 22, 2, 12},
+				{3, 13, 23, 3, 13},
+			},
+		},
+	}
+	for _, tt := range tests {
+		if err := tt.format.Validate(tt.card); err != nil {
+			t.Fatalf("%s: %v", tt.format.Name(), err)
+		}
+		if got := tt.format.ChainRows(tt.card); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.format.Name(), got, tt.want)
+		}
+	}
+}
+
+// TestChainRowsDistinct checks that 75-ball cards differing only in a row
+// check_prize does not read are still different cards for join_game.
+func TestChainRowsDistinct(t *testing.T) {
+	card := Card{
+		{1, 16, 31, 46, 61},
+		{2, 17, 32, 47, 62},
+		{3, 18, 0, 48, 63},
+		{4, 19, 34, 49, 64},
+		{5, 20, 35, 50, 65},
+	}
+	rows := SeventyFiveBall.ChainRows(card).Flatten()
+	for _, r := range []int{1, 3} {
+		other := slices.Clone(card)
+		other[r] = []int{6, 21, 36, 51, 66}
+		if SeventyFiveBall.ChainRows(other).Flatten() == rows {
+			t.Errorf("cards differing in row %d have the same rows", r)
+		}
+	}
+}
+
+func TestLineDrawn(t *testing.T) {
+	drawn := map[int]bool{1: true, 11: true, 21: true, 31: true, 41: true, 3: true, 18: true, 48: true, 63: true}
+	tests := []struct {
+		name string
+		row  []int
+		want bool
+	}{
+		{"90-ball row", []int{1, 0, 21, 0, 41, 0, 0, 11, 31}, true},
+		{"90-ball row not drawn", []int{1, 0, 21, 0, 41, 0, 0, 12, 31}, false},
+		{"past the first 5 numbers", []int{1, 11, 21, 31, 41, 2, 12}, true},
+		{"75-ball centre line", []int{3, 18, 3, 48, 63, 4, 19}, true},
+		{"30-ball row", []int{1, 11, 21, 1, 11}, true},
+		{"fewer than 5 numbers", []int{1, 11, 21}, false},
+		// A fifth blank is read as a number.
+		{"more than 4 blanks", []int{0, 0, 0, 0, 0, 1, 11, 21, 31}, false},
+	}
+	for _, tt := range tests {
+		if got := LineDrawn(tt.row, drawn); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package indexer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// urls are the GraphQL endpoints of the Aptos Labs indexer per network.
+var urls = map[string]string{
+	"mainnet": "https://api.mainnet.aptoslabs.com/v1/graphql",
+	"testnet": "https://api.testnet.aptoslabs.com/v1/graphql",
+	"devnet":  "https://api.devnet.aptoslabs.com/v1/graphql",
+	"random":  "https://indexer.random.aptoslabs.com/v1/graphql",
+}
+
+const defaultNetwork = "random"
+
+const defaultTimeout = 10 * time.Second
+
+var ErrEventNotFound = errors.New("event not found")
+
+// Error is an error reported by the GraphQL server in the errors array of a
+// response.
+type Error struct {
+	Messages []string
+}
+
+func (e *Error) Error() string {
+	return "indexer: " + strings.Join(e.Messages, "; ")
+}
+
+// Client queries the indexer GraphQL API. Every query is bounded by Timeout
+// on top of the deadline of the caller's context.
+type Client struct {
+	URL     string
+	HTTP    *http.Client
+	Timeout time.Duration
+	// Retries and RetryDelay bound how long WaitForEvent waits for the
+	// indexer to catch up with the node.
+	Retries    int
+	RetryDelay time.Duration
+}
+
+func NewClient(url string) *Client {
+	return &Client{
+		URL:        url,
+		HTTP:       &http.Client{},
+		Timeout:    defaultTimeout,
+		Retries:    10,
+		RetryDelay: time.Second,
+	}
+}
+
+// NewClientFromEnv uses INDEXER_URL when set, otherwise the endpoint of
+// APTOS_NETWORK (mainnet, testnet, devnet or random, the default).
+func NewClientFromEnv() (*Client, error) {
+	if url := os.Getenv("INDEXER_URL"); url != "" {
+		return NewClient(url), nil
+	}
+	network := os.Getenv("APTOS_NETWORK")
+	if network == "" {
+		network = defaultNetwork
+	}
+	url, ok := urls[strings.ToLower(network)]
+	if !ok {
+		return nil, fmt.Errorf("unknown APTOS_NETWORK %q", network)
+	}
+	return NewClient(url), nil
+}
+
+// Query runs a GraphQL query and decodes the data of the response into out.
+func (c *Client) Query(ctx context.Context, query string, variables map[string]interface{}, out interface{}) error {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+	body, err := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("indexer returned %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	var result struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("invalid indexer response: %w", err)
+	}
+	if len(result.Errors) > 0 {
+		e := &Error{}
+		for _, m := range result.Errors {
+			e.Messages = append(e.Messages, m.Message)
+		}
+		return e
+	}
+	return json.Unmarshal(result.Data, out)
+}
@@ -0,0 +1,342 @@
+package indexer
+
+import (
+	"VirtueGaming/utils/smartcontract"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxPageSize is the largest page the indexer returns.
+const MaxPageSize = 100
+
+const eventsQuery = `
+	query Events($types: [String!]!, $data: jsonb!, $from: bigint!, $limit: Int!, $offset: Int!) {
+		events(
+			where: {
+				type: { _in: $types },
+				data: { _contains: $data },
+				transaction_version: { _gte: $from }
+			}
+			order_by: [{ transaction_version: asc }, { event_index: asc }]
+			limit: $limit
+			offset: $offset
+		) {
+			transaction_version
+			event_index
+			type
+			data
+		}
+	}
+`
+
+const eventByVersionQuery = `
+	query EventByVersion($version: bigint!, $type: String!) {
+		events(
+			where: {
+				transaction_version: { _eq: $version },
+				type: { _eq: $type }
+			}
+			limit: 1
+		) {
+			transaction_version
+			event_index
+			type
+			data
+		}
+	}
+`
+
+// Meta locates an event on chain.
+type Meta struct {
+	TransactionVersion int64  `json:"transaction_version"`
+	EventIndex         int64  `json:"event_index"`
+	Type               string `json:"type"`
+}
+
+// Record is an event as the indexer returns it, Data holds the event struct
+// with u64 fields encoded as strings.
+type Record struct {
+	Meta
+	Data json.RawMessage `json:"data"`
+}
+
+// Filter selects the events of a query. The zero value returns the first page
+// of all events of a type.
+type Filter struct {
+	// GameID restricts the events to one game when set.
+	GameID *int
+	// FromVersion skips the events of earlier transactions.
+	FromVersion int64
+	// Limit is the page size, defaulting to and capped at MaxPageSize.
+	Limit  int
+	Offset int
+}
+
+// Game returns a filter for the events of a single game.
+func Game(gameID int) Filter {
+	return Filter{GameID: &gameID}
+}
+
+// CreateGameEvent is emitted by create_game of both bingo and SNL.
+type CreateGameEvent struct {
+	Meta           `json:"-"`
+	Creator        string `json:"creator"`
+	GameName       string `json:"game_name"`
+	GameID         int    `json:"game_id,string"`
+	StartTimestamp int64  `json:"start_timestamp,string"`
+	Timestamp      int64  `json:"timestamp,string"`
+}
+
+// GameEvent is the shape of CancelGameEvent, GameStartedEvent and
+// GameEndedEvent, which only carry the game.
+type GameEvent struct {
+	Meta      `json:"-"`
+	GameID    int   `json:"game_id,string"`
+	Timestamp int64 `json:"timestamp,string"`
+}
+
+type DrawNumberEvent struct {
+	Meta      `json:"-"`
+	GameID    int   `json:"game_id,string"`
+	Number    int   `json:"number,string"`
+	Timestamp int64 `json:"timestamp,string"`
+}
+
+// PlayerEvent is the shape of the bingo JoinGameEvent and BingoEvent.
+type PlayerEvent struct {
+	Meta      `json:"-"`
+	GameID    int    `json:"game_id,string"`
+	Player    string `json:"player"`
+	Timestamp int64  `json:"timestamp,string"`
+}
+
+// UserEvent is the shape of the SNL JoinGameEvent and GameWonEvent.
+type UserEvent struct {
+	Meta      `json:"-"`
+	GameID    int    `json:"game_id,string"`
+	User      string `json:"user"`
+	Timestamp int64  `json:"timestamp,string"`
+}
+
+type RollDiceEvent struct {
+	Meta      `json:"-"`
+	GameID    int    `json:"game_id,string"`
+	User      string `json:"user"`
+	Number    int    `json:"number,string"`
+	Timestamp int64  `json:"timestamp,string"`
+}
+
+// Events returns one page of the events of type eventType, oldest first.
+func (c *Client) Events(ctx context.Context, eventType string, f Filter) ([]Record, error) {
+	return c.EventsOfTypes(ctx, []string{eventType}, f)
+}
+
+// EventsOfTypes returns one page of the events of any of the given types in
+// the order they were emitted.
+func (c *Client) EventsOfTypes(ctx context.Context, types []string, f Filter) ([]Record, error) {
+	limit := f.Limit
+	if limit <= 0 || limit > MaxPageSize {
+		limit = MaxPageSize
+	}
+	data := map[string]string{}
+	if f.GameID != nil {
+		data["game_id"] = strconv.Itoa(*f.GameID)
+	}
+	var result struct {
+		Events []Record `json:"events"`
+	}
+	err := c.Query(ctx, eventsQuery, map[string]interface{}{
+		"types":  types,
+		"data":   data,
+		"from":   f.FromVersion,
+		"limit":  limit,
+		"offset": f.Offset,
+	}, &result)
+	if err != nil {
+		return nil, fmt.Errorf("query %s: %w", strings.Join(types, ", "), err)
+	}
+	return result.Events, nil
+}
+
+// EachEvent pages through all events of the given types matching f, starting
+// at f.Offset, and calls fn for each of them until fn returns an error.
+func (c *Client) EachEvent(ctx context.Context, types []string, f Filter, fn func(Record) error) error {
+	if f.Limit <= 0 || f.Limit > MaxPageSize {
+		f.Limit = MaxPageSize
+	}
+	for {
+		page, err := c.EventsOfTypes(ctx, types, f)
+		if err != nil {
+			return err
+		}
+		for _, r := range page {
+			if err := fn(r); err != nil {
+				return err
+			}
+		}
+		if len(page) < f.Limit {
+			return nil
+		}
+		f.Offset += len(page)
+	}
+}
+
+// Decode unmarshals the data of the record into out.
+func (r Record) Decode(out interface{}) error {
+	if err := json.Unmarshal(r.Data, out); err != nil {
+		return fmt.Errorf("decode %s at version %d: %w", r.Type, r.TransactionVersion, err)
+	}
+	return nil
+}
+
+// decodeEvents queries a page of events and decodes each of them with add.
+func (c *Client) decodeEvents(ctx context.Context, eventType string, f Filter, add func(Record) error) error {
+	records, err := c.Events(ctx, eventType, f)
+	if err != nil {
+		return err
+	}
+	for _, r := range records {
+		if err := add(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Client) CreateGameEvents(ctx context.Context, module smartcontract.Module, f Filter) ([]CreateGameEvent, error) {
+	var events []CreateGameEvent
+	err := c.decodeEvents(ctx, module.EventType("CreateGameEvent"), f, func(r Record) error {
+		events = append(events, CreateGameEvent{Meta: r.Meta})
+		return r.Decode(&events[len(events)-1])
+	})
+	return events, err
+}
+
+func (c *Client) gameEvents(ctx context.Context, eventType string, f Filter) ([]GameEvent, error) {
+	var events []GameEvent
+	err := c.decodeEvents(ctx, eventType, f, func(r Record) error {
+		events = append(events, GameEvent{Meta: r.Meta})
+		return r.Decode(&events[len(events)-1])
+	})
+	return events, err
+}
+
+func (c *Client) GameStartedEvents(ctx context.Context, module smartcontract.Module, f Filter) ([]GameEvent, error) {
+	return c.gameEvents(ctx, module.EventType("GameStartedEvent"), f)
+}
+
+func (c *Client) GameEndedEvents(ctx context.Context, module smartcontract.Module, f Filter) ([]GameEvent, error) {
+	return c.gameEvents(ctx, module.EventType("GameEndedEvent"), f)
+}
+
+func (c *Client) CancelGameEvents(ctx context.Context, module smartcontract.Module, f Filter) ([]GameEvent, error) {
+	return c.gameEvents(ctx, module.EventType("CancelGameEvent"), f)
+}
+
+func (c *Client) DrawNumberEvents(ctx context.Context, module smartcontract.Module, f Filter) ([]DrawNumberEvent, error) {
+	var events []DrawNumberEvent
+	err := c.decodeEvents(ctx, module.EventType("DrawNumberEvent"), f, func(r Record) error {
+		events = append(events, DrawNumberEvent{Meta: r.Meta})
+		return r.Decode(&events[len(events)-1])
+	})
+	return events, err
+}
+
+func (c *Client) playerEvents(ctx context.Context, eventType string, f Filter) ([]PlayerEvent, error) {
+	var events []PlayerEvent
+	err := c.decodeEvents(ctx, eventType, f, func(r Record) error {
+		events = append(events, PlayerEvent{Meta: r.Meta})
+		return r.Decode(&events[len(events)-1])
+	})
+	return events, err
+}
+
+// JoinGameEvents returns the bingo cards bought.
+func (c *Client) JoinGameEvents(ctx context.Context, module smartcontract.Module, f Filter) ([]PlayerEvent, error) {
+	return c.playerEvents(ctx, module.EventType("JoinGameEvent"), f)
+}
+
+// BingoEvents returns the full house prizes paid out.
+func (c *Client) BingoEvents(ctx context.Context, module smartcontract.Module, f Filter) ([]PlayerEvent, error) {
+	return c.playerEvents(ctx, module.EventType("BingoEvent"), f)
+}
+
+func (c *Client) userEvents(ctx context.Context, eventType string, f Filter) ([]UserEvent, error) {
+	var events []UserEvent
+	err := c.decodeEvents(ctx, eventType, f, func(r Record) error {
+		events = append(events, UserEvent{Meta: r.Meta})
+		return r.Decode(&events[len(events)-1])
+	})
+	return events, err
+}
+
+// SnlJoinGameEvents returns the SNL avatars bought.
+func (c *Client) SnlJoinGameEvents(ctx context.Context, module smartcontract.Module, f Filter) ([]UserEvent, error) {
+	return c.userEvents(ctx, module.EventType("JoinGameEvent"), f)
+}
+
+func (c *Client) GameWonEvents(ctx context.Context, module smartcontract.Module, f Filter) ([]UserEvent, error) {
+	return c.userEvents(ctx, module.EventType("GameWonEvent"), f)
+}
+
+func (c *Client) RollDiceEvents(ctx context.Context, module smartcontract.Module, f Filter) ([]RollDiceEvent, error) {
+	var events []RollDiceEvent
+	err := c.decodeEvents(ctx, module.EventType("RollDiceEvent"), f, func(r Record) error {
+		events = append(events, RollDiceEvent{Meta: r.Meta})
+		return r.Decode(&events[len(events)-1])
+	})
+	return events, err
+}
+
+// EventByVersion decodes the event of type eventType emitted by the
+// transaction at version into out.
+func (c *Client) EventByVersion(ctx context.Context, version int64, eventType string, out interface{}) error {
+	var result struct {
+		Events []Record `json:"events"`
+	}
+	err := c.Query(ctx, eventByVersionQuery, map[string]interface{}{"version": version, "type": eventType}, &result)
+	if err != nil {
+		return fmt.Errorf("query %s: %w", eventType, err)
+	}
+	if len(result.Events) == 0 {
+		return ErrEventNotFound
+	}
+	return result.Events[0].Decode(out)
+}
+
+// WaitForEvent retries EventByVersion until the indexer has caught up with
+// the transaction, giving up after c.Retries tries.
+func (c *Client) WaitForEvent(ctx context.Context, version int64, eventType string, out interface{}) error {
+	var err error
+	for i := 0; i == 0 || i < c.Retries; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(c.RetryDelay):
+			}
+		}
+		if err = c.EventByVersion(ctx, version, eventType, out); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// TxEvent decodes the first event of type eventType emitted by tx into out.
+// The events are taken from the transaction itself and only looked up on the
+// indexer, by transaction version, when the node did not return them.
+func (c *Client) TxEvent(ctx context.Context, tx *smartcontract.TxResult, eventType string, out interface{}) error {
+	found, err := tx.Event(eventType, out)
+	if err != nil || found {
+		return err
+	}
+	if err := c.WaitForEvent(ctx, tx.Result.Version, eventType, out); err != nil {
+		return fmt.Errorf("%s of transaction %s: %w", eventType, tx.Result.TransactionHash, err)
+	}
+	return nil
+}
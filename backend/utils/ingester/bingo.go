@@ -0,0 +1,107 @@
+package ingester
+
+import (
+	"VirtueGaming/models"
+	"VirtueGaming/utils/indexer"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+var bingoEvents = map[string]applyFunc{
+	"CreateGameEvent":  createBingoGame,
+	"JoinGameEvent":    joinBingoGame,
+	"DrawNumberEvent":  drawNumber,
+	"BingoEvent":       bingo,
+	"GameStartedEvent": startBingoGame,
+	"GameEndedEvent":   endBingoGame,
+	"CancelGameEvent":  setStatus(&models.Game{}, models.GameStatusCancelled),
+}
+
+// createBingoGame stores games created outside of the API. The API stores its
+// own games with more details, whichever of the two comes first.
+func createBingoGame(tx *gorm.DB, module string, r indexer.Record) error {
+	var e indexer.CreateGameEvent
+	if err := r.Decode(&e); err != nil {
+		return err
+	}
+	var g models.Game
+	return game(tx, &models.Game{}, e.GameID, module).
+		Attrs(models.Game{
+			Name:                 e.GameName,
+			StartTimestamp:       strconv.FormatInt(e.StartTimestamp, 10),
+			CreatorWalletAddress: e.Creator,
+			GameId:               e.GameID,
+			Module:               module,
+			Status:               models.GameStatusNotStarted,
+		}).
+		FirstOrCreate(&g).Error
+}
+
+// startBingoGame also records the start as the last draw, the interval to the
+// first number counts from it.
+func startBingoGame(tx *gorm.DB, module string, r indexer.Record) error {
+	var e indexer.GameEvent
+	if err := r.Decode(&e); err != nil {
+		return err
+	}
+	return game(tx, &models.Game{}, e.GameID, module).
+		Updates(map[string]interface{}{"status": models.GameStatusStarted, "last_drawn_at": e.Timestamp}).Error
+}
+
+func joinBingoGame(tx *gorm.DB, module string, r indexer.Record) error {
+	var e indexer.PlayerEvent
+	if err := r.Decode(&e); err != nil {
+		return err
+	}
+	return game(tx, &models.Game{}, e.GameID, module).
+		Update("cards_sold", gorm.Expr("COALESCE(cards_sold, 0) + 1")).Error
+}
+
+func drawNumber(tx *gorm.DB, module string, r indexer.Record) error {
+	var e indexer.DrawNumberEvent
+	if err := r.Decode(&e); err != nil {
+		return err
+	}
+	if err := game(tx, &models.Game{}, e.GameID, module).Updates(map[string]interface{}{
+		"drawn_numbers": gorm.Expr("array_append(drawn_numbers, ?)", e.Number),
+		"last_drawn_at": e.Timestamp,
+	}).Error; err != nil {
+		return err
+	}
+	drawn := models.DrawnNumber{GameId: e.GameID, Module: module, Number: e.Number}
+	if err := tx.Where(&drawn).FirstOrCreate(&drawn).Error; err != nil {
+		return err
+	}
+	return payClaims(tx, module, e.GameID, r.TransactionVersion)
+}
+
+// bingo records a full house winner.
+func bingo(tx *gorm.DB, module string, r indexer.Record) error {
+	var e indexer.PlayerEvent
+	if err := r.Decode(&e); err != nil {
+		return err
+	}
+	return game(tx, &models.Game{}, e.GameID, module).
+		Update("winners", gorm.Expr("array_append(winners, ?)", e.Player)).Error
+}
+
+func endBingoGame(tx *gorm.DB, module string, r indexer.Record) error {
+	var e indexer.GameEvent
+	if err := r.Decode(&e); err != nil {
+		return err
+	}
+	if err := game(tx, &models.Game{}, e.GameID, module).Update("status", models.GameStatusFinished).Error; err != nil {
+		return err
+	}
+	return payClaims(tx, module, e.GameID, r.TransactionVersion)
+}
+
+// payClaims marks the claims submitted before a draw as paid, draw_number
+// distributes the pending prizes before anything else.
+func payClaims(tx *gorm.DB, module string, gameID int, version int64) error {
+	return tx.Model(&models.Claim{}).
+		Where("game_id = ? AND module = ? AND status = ? AND transaction_version > 0 AND transaction_version < ?",
+			gameID, module, models.ClaimStatusPending, version).
+		Update("status", models.ClaimStatusPaid).Error
+}
@@ -0,0 +1,161 @@
+package ingester
+
+import (
+	"VirtueGaming/models"
+	"VirtueGaming/utils/indexer"
+	"VirtueGaming/utils/smartcontract"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const defaultInterval = 5 * time.Second
+
+// applyFunc updates the game tables for one event, inside the database
+// transaction that also advances the checkpoint.
+type applyFunc func(tx *gorm.DB, module string, r indexer.Record) error
+
+// stream is the events of one module, applied in the order they were emitted.
+type stream struct {
+	module smartcontract.Module
+	apply  map[string]applyFunc
+}
+
+// Ingester streams the events of the bingo and SNL modules from the indexer
+// into Postgres. Every module has a checkpoint, so a restarted ingester picks
+// up after the last event it applied.
+type Ingester struct {
+	indexer  *indexer.Client
+	db       *gorm.DB
+	Interval time.Duration
+	streams  []stream
+}
+
+func New(idx *indexer.Client, db *gorm.DB, bingo, snl smartcontract.Module) *Ingester {
+	return &Ingester{
+		indexer:  idx,
+		db:       db,
+		Interval: defaultInterval,
+		streams: []stream{
+			{module: bingo, apply: bingoEvents},
+			{module: snl, apply: snlEvents},
+		},
+	}
+}
+
+// IntervalFromEnv reads INGEST_INTERVAL, a duration such as "5s".
+func IntervalFromEnv() (time.Duration, error) {
+	v := os.Getenv("INGEST_INTERVAL")
+	if v == "" {
+		return defaultInterval, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid INGEST_INTERVAL: %w", err)
+	}
+	return d, nil
+}
+
+// Run syncs every Interval until ctx is done.
+func (in *Ingester) Run(ctx context.Context) {
+	ticker := time.NewTicker(in.Interval)
+	defer ticker.Stop()
+	for {
+		for _, s := range in.streams {
+			if err := in.sync(ctx, s); err != nil && ctx.Err() == nil {
+				logrus.Errorf("failed to sync %s events: %s", s.module.ID(), err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sync applies the events emitted since the checkpoint of the stream.
+func (in *Ingester) sync(ctx context.Context, s stream) error {
+	module := s.module.ID()
+	cp := models.EventCheckpoint{Module: module, EventIndex: -1}
+	if err := in.db.Where("module = ?", module).FirstOrInit(&cp).Error; err != nil {
+		return err
+	}
+	types := make([]string, 0, len(s.apply))
+	for name := range s.apply {
+		types = append(types, s.module.EventType(name))
+	}
+	filter := indexer.Filter{FromVersion: cp.TransactionVersion}
+	return in.indexer.EachEvent(ctx, types, filter, func(r indexer.Record) error {
+		if r.TransactionVersion == cp.TransactionVersion && r.EventIndex <= cp.EventIndex {
+			return nil
+		}
+		apply := s.apply[strings.TrimPrefix(r.Type, module+"::")]
+		if apply == nil {
+			return fmt.Errorf("unexpected event %s", r.Type)
+		}
+		err := in.db.Transaction(func(tx *gorm.DB) error {
+			saved, err := saveEvent(tx, module, r)
+			if err != nil {
+				return err
+			}
+			// Another ingester already applied the event, the counters and
+			// arrays it updates must not move twice.
+			if saved {
+				if err := apply(tx, module, r); err != nil {
+					return err
+				}
+			}
+			cp.TransactionVersion = r.TransactionVersion
+			cp.EventIndex = r.EventIndex
+			return tx.Save(&cp).Error
+		})
+		if err != nil {
+			return fmt.Errorf("apply %s at version %d: %w", r.Type, r.TransactionVersion, err)
+		}
+		return nil
+	})
+}
+
+// saveEvent stores r and reports whether it was new.
+func saveEvent(tx *gorm.DB, module string, r indexer.Record) (bool, error) {
+	var data struct {
+		GameID int `json:"game_id,string"`
+	}
+	if err := json.Unmarshal(r.Data, &data); err != nil {
+		return false, err
+	}
+	res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.ChainEvent{
+		Module:             module,
+		Type:               r.Type,
+		GameId:             data.GameID,
+		TransactionVersion: r.TransactionVersion,
+		EventIndex:         r.EventIndex,
+		Data:               string(r.Data),
+	})
+	return res.RowsAffected == 1, res.Error
+}
+
+// game scopes a query to one game. Rows stored before the module was recorded
+// match any module, like the lookups of the API handlers.
+func game(tx *gorm.DB, model interface{}, gameID int, module string) *gorm.DB {
+	return tx.Model(model).Where("game_id = ? AND (module = ? OR module = '')", gameID, module)
+}
+
+// setStatus returns an applyFunc moving a game of model to status.
+func setStatus(model interface{}, status string) applyFunc {
+	return func(tx *gorm.DB, module string, r indexer.Record) error {
+		var e indexer.GameEvent
+		if err := r.Decode(&e); err != nil {
+			return err
+		}
+		return game(tx, model, e.GameID, module).Update("status", status).Error
+	}
+}
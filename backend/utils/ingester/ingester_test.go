@@ -0,0 +1,206 @@
+package ingester
+
+import (
+	"VirtueGaming/models"
+	"VirtueGaming/utils/indexer"
+	"VirtueGaming/utils/smartcontract"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var (
+	testBingo = smartcontract.Module{Address: "0x1", Name: "bingov2"}
+	testSnl   = smartcontract.Module{Address: "0x1", Name: "SNL"}
+)
+
+// fakeIndexer answers the events query of indexer.Client from events, which
+// tests may append to between syncs.
+type fakeIndexer struct {
+	mu     sync.Mutex
+	events []indexer.Record
+}
+
+func (f *fakeIndexer) add(version, index int64, module smartcontract.Module, name string, data map[string]string) {
+	raw, _ := json.Marshal(data)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.events = append(f.events, indexer.Record{
+		Meta: indexer.Meta{TransactionVersion: version, EventIndex: index, Type: module.EventType(name)},
+		Data: raw,
+	})
+}
+
+func (f *fakeIndexer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Variables struct {
+			Types  []string `json:"types"`
+			From   int64    `json:"from"`
+			Limit  int      `json:"limit"`
+			Offset int      `json:"offset"`
+		} `json:"variables"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	v := req.Variables
+	f.mu.Lock()
+	var matched []indexer.Record
+	for _, e := range f.events {
+		for _, t := range v.Types {
+			if e.Type == t && e.TransactionVersion >= v.From {
+				matched = append(matched, e)
+			}
+		}
+	}
+	f.mu.Unlock()
+	sort.Slice(matched, func(i, j int) bool {
+		if matched[i].TransactionVersion != matched[j].TransactionVersion {
+			return matched[i].TransactionVersion < matched[j].TransactionVersion
+		}
+		return matched[i].EventIndex < matched[j].EventIndex
+	})
+	page := []indexer.Record{}
+	for i := v.Offset; i < len(matched) && len(page) < v.Limit; i++ {
+		page = append(page, matched[i])
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"events": page}})
+}
+
+func newTestIngester(t *testing.T) (*Ingester, *fakeIndexer, *gorm.DB) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&models.Game{}, &models.SnlGame{}, &models.SnlRoll{}, &models.ChainEvent{}, &models.EventCheckpoint{}); err != nil {
+		t.Fatal(err)
+	}
+	idx := &fakeIndexer{}
+	srv := httptest.NewServer(idx)
+	t.Cleanup(srv.Close)
+	return New(indexer.NewClient(srv.URL), db, testBingo, testSnl), idx, db
+}
+
+func syncAll(t *testing.T, in *Ingester) {
+	t.Helper()
+	for _, s := range in.streams {
+		if err := in.sync(context.Background(), s); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func snlGame(t *testing.T, db *gorm.DB) models.SnlGame {
+	t.Helper()
+	var g models.SnlGame
+	if err := db.Where("game_id = ? AND module = ?", 0, testSnl.ID()).First(&g).Error; err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func checkpoint(t *testing.T, db *gorm.DB, module smartcontract.Module) models.EventCheckpoint {
+	t.Helper()
+	var cp models.EventCheckpoint
+	if err := db.Where("module = ?", module.ID()).First(&cp).Error; err != nil {
+		t.Fatal(err)
+	}
+	return cp
+}
+
+func TestSyncCheckpoint(t *testing.T) {
+	in, idx, db := newTestIngester(t)
+	idx.add(10, 0, testSnl, "CreateGameEvent", map[string]string{"creator": "0xc", "game_name": "Snakes", "game_id": "0", "start_timestamp": "100"})
+	idx.add(11, 0, testSnl, "JoinGameEvent", map[string]string{"game_id": "0", "user": "0xa"})
+	idx.add(12, 0, testSnl, "GameStartedEvent", map[string]string{"game_id": "0"})
+	idx.add(13, 0, testSnl, "RollDiceEvent", map[string]string{"game_id": "0", "user": "0xa", "number": "7"})
+	syncAll(t, in)
+
+	g := snlGame(t, db)
+	if g.Name != "Snakes" || g.Players != 1 || g.Status != models.GameStatusStarted {
+		t.Errorf("game %q with %d players, status %q", g.Name, g.Players, g.Status)
+	}
+	if cp := checkpoint(t, db, testSnl); cp.TransactionVersion != 13 || cp.EventIndex != 0 {
+		t.Errorf("checkpoint at %d/%d, want 13/0", cp.TransactionVersion, cp.EventIndex)
+	}
+
+	// The next sync queries from the checkpoint version again: the events
+	// up to it are skipped, the later ones of the same transaction applied.
+	idx.add(13, 1, testSnl, "JoinGameEvent", map[string]string{"game_id": "0", "user": "0xb"})
+	idx.add(14, 0, testSnl, "GameWonEvent", map[string]string{"game_id": "0", "user": "0xa"})
+	syncAll(t, in)
+
+	g = snlGame(t, db)
+	if g.Players != 2 {
+		t.Errorf("got %d players, want 2", g.Players)
+	}
+	// game_won does not end the game.
+	if g.Winner != "0xa" || g.Status != models.GameStatusStarted {
+		t.Errorf("winner %q, status %q", g.Winner, g.Status)
+	}
+	var rolls int64
+	db.Model(&models.SnlRoll{}).Count(&rolls)
+	if rolls != 1 {
+		t.Errorf("got %d rolls, want 1", rolls)
+	}
+	if cp := checkpoint(t, db, testSnl); cp.TransactionVersion != 14 || cp.EventIndex != 0 {
+		t.Errorf("checkpoint at %d/%d, want 14/0", cp.TransactionVersion, cp.EventIndex)
+	}
+}
+
+// TestSyncDedup replays events another ingester applied already, as when two
+// replicas read the same checkpoint: they move the checkpoint but not the
+// game.
+func TestSyncDedup(t *testing.T) {
+	in, idx, db := newTestIngester(t)
+	idx.add(10, 0, testSnl, "CreateGameEvent", map[string]string{"creator": "0xc", "game_name": "Snakes", "game_id": "0", "start_timestamp": "100"})
+	idx.add(11, 0, testSnl, "JoinGameEvent", map[string]string{"game_id": "0", "user": "0xa"})
+	idx.add(12, 0, testSnl, "RollDiceEvent", map[string]string{"game_id": "0", "user": "0xa", "number": "7"})
+	syncAll(t, in)
+
+	if err := db.Where("module = ?", testSnl.ID()).Delete(&models.EventCheckpoint{}).Error; err != nil {
+		t.Fatal(err)
+	}
+	syncAll(t, in)
+
+	if g := snlGame(t, db); g.Players != 1 {
+		t.Errorf("got %d players, want 1", g.Players)
+	}
+	var rolls, events int64
+	db.Model(&models.SnlRoll{}).Count(&rolls)
+	db.Model(&models.ChainEvent{}).Count(&events)
+	if rolls != 1 || events != 3 {
+		t.Errorf("got %d rolls and %d events, want 1 and 3", rolls, events)
+	}
+	if cp := checkpoint(t, db, testSnl); cp.TransactionVersion != 12 {
+		t.Errorf("checkpoint at version %d, want 12", cp.TransactionVersion)
+	}
+}
+
+func TestSyncPages(t *testing.T) {
+	in, idx, db := newTestIngester(t)
+	idx.add(10, 0, testSnl, "CreateGameEvent", map[string]string{"creator": "0xc", "game_name": "Snakes", "game_id": "0", "start_timestamp": "100"})
+	players := indexer.MaxPageSize + 20
+	for i := 0; i < players; i++ {
+		idx.add(int64(11+i), 0, testSnl, "JoinGameEvent", map[string]string{"game_id": "0", "user": fmt.Sprintf("0x%x", i)})
+	}
+	syncAll(t, in)
+
+	if g := snlGame(t, db); g.Players != players {
+		t.Errorf("got %d players, want %d", g.Players, players)
+	}
+	if cp := checkpoint(t, db, testSnl); cp.TransactionVersion != int64(10+players) {
+		t.Errorf("checkpoint at version %d, want %d", cp.TransactionVersion, 10+players)
+	}
+}
@@ -0,0 +1,70 @@
+package ingester
+
+import (
+	"VirtueGaming/models"
+	"VirtueGaming/utils/indexer"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+var snlEvents = map[string]applyFunc{
+	"CreateGameEvent":  createSnlGame,
+	"JoinGameEvent":    joinSnlGame,
+	"RollDiceEvent":    rollDice,
+	"GameWonEvent":     gameWon,
+	"GameStartedEvent": setStatus(&models.SnlGame{}, models.GameStatusStarted),
+	"GameEndedEvent":   setStatus(&models.SnlGame{}, models.GameStatusFinished),
+	"CancelGameEvent":  setStatus(&models.SnlGame{}, models.GameStatusCancelled),
+}
+
+func createSnlGame(tx *gorm.DB, module string, r indexer.Record) error {
+	var e indexer.CreateGameEvent
+	if err := r.Decode(&e); err != nil {
+		return err
+	}
+	var g models.SnlGame
+	return game(tx, &models.SnlGame{}, e.GameID, module).
+		Attrs(models.SnlGame{
+			Name:                 e.GameName,
+			StartTimestamp:       strconv.FormatInt(e.StartTimestamp, 10),
+			CreatorWalletAddress: e.Creator,
+			GameId:               e.GameID,
+			Module:               module,
+			Status:               models.GameStatusNotStarted,
+		}).
+		FirstOrCreate(&g).Error
+}
+
+func joinSnlGame(tx *gorm.DB, module string, r indexer.Record) error {
+	var e indexer.UserEvent
+	if err := r.Decode(&e); err != nil {
+		return err
+	}
+	return game(tx, &models.SnlGame{}, e.GameID, module).
+		Update("players", gorm.Expr("COALESCE(players, 0) + 1")).Error
+}
+
+func rollDice(tx *gorm.DB, module string, r indexer.Record) error {
+	var e indexer.RollDiceEvent
+	if err := r.Decode(&e); err != nil {
+		return err
+	}
+	return tx.Create(&models.SnlRoll{
+		GameId:             e.GameID,
+		Module:             module,
+		User:               e.User,
+		Number:             e.Number,
+		TransactionVersion: r.TransactionVersion,
+	}).Error
+}
+
+// gameWon records the winner. game_won does not end the game, GameEndedEvent
+// and CancelGameEvent do.
+func gameWon(tx *gorm.DB, module string, r indexer.Record) error {
+	var e indexer.UserEvent
+	if err := r.Decode(&e); err != nil {
+		return err
+	}
+	return game(tx, &models.SnlGame{}, e.GameID, module).Update("winner", e.User).Error
+}
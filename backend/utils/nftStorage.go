@@ -83,6 +83,9 @@ func UploadImageToNFTStorage(apiKey string, fileData []byte) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	if !response.Ok || response.Value.Cid == "" {
+		return "", fmt.Errorf("nft.storage upload failed: %s", resp.Status)
+	}
 	return response.Value.Cid, nil
 }
 
@@ -149,5 +152,8 @@ func UploadMetadataToNFTStorage(apiKey string, jsonData []byte) (string, error)
 	if err != nil {
 		return "", err
 	}
+	if !response.Ok || response.Value.Cid == "" {
+		return "", fmt.Errorf("nft.storage upload failed: %s", resp.Status)
+	}
 	return response.Value.Cid, nil
 }
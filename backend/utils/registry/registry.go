@@ -0,0 +1,93 @@
+package registry
+
+import (
+	"VirtueGaming/models"
+	"VirtueGaming/utils"
+	"VirtueGaming/utils/aptos"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// defaultMaxAttempts bounds the tickets drawn for one wallet. With 15 of 90
+// numbers on a card, a second collision in a row is already unheard of.
+const defaultMaxAttempts = 16
+
+var ErrExhausted = errors.New("no ticket left that is not issued already")
+
+// Registry records the tickets issued for every game, keyed by the hash of
+// their grid, so no two wallets are handed the same card: bingov2 aborts the
+// second join_game with ERROR_DUPLICATED_TICKET, after the gas is paid.
+type Registry struct {
+	db          *gorm.DB
+	seeder      *utils.TicketSeeder
+	MaxAttempts int
+}
+
+func New(db *gorm.DB, seeder *utils.TicketSeeder) *Registry {
+	return &Registry{db: db, seeder: seeder, MaxAttempts: defaultMaxAttempts}
+}
+
+// Issue returns the card of wallet in a game. The card is drawn from the seed
+// of the game, again with the next nonce as long as another wallet holds the
+// same grid. A wallet asking again gets the card it was issued before.
+func (r *Registry) Issue(f utils.CardFormat, module string, gameID int, wallet string) (*models.IssuedTicket, utils.Card, error) {
+	for nonce := 0; nonce < r.MaxAttempts; nonce++ {
+		card := f.Generate(r.seeder.Seed(module, gameID, wallet, nonce))
+		issued := models.IssuedTicket{
+			Module:        module,
+			GameId:        gameID,
+			Hash:          card.Hash(),
+			WalletAddress: wallet,
+			Ticket:        card.Flatten(),
+			Nonce:         nonce,
+		}
+		taken, err := r.takenByTicket(issued)
+		if err != nil {
+			return nil, nil, err
+		}
+		if taken {
+			continue
+		}
+		res := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&issued)
+		if res.Error != nil {
+			return nil, nil, res.Error
+		}
+		if res.RowsAffected == 1 {
+			return &issued, card, nil
+		}
+		var holder models.IssuedTicket
+		if err := r.db.Where("module = ? AND game_id = ? AND hash = ?", module, gameID, issued.Hash).
+			First(&holder).Error; err != nil {
+			return nil, nil, err
+		}
+		if sameWallet(holder.WalletAddress, wallet) {
+			return &holder, card, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("%w for %s in game %d of %s", ErrExhausted, wallet, gameID, module)
+}
+
+// takenByTicket reports whether another wallet bought the grid before the
+// registry existed.
+func (r *Registry) takenByTicket(issued models.IssuedTicket) (bool, error) {
+	var tickets []models.Ticket
+	if err := r.db.Model(&models.Ticket{}).
+		Where("module = ? AND game_id = ? AND ticket = ?", issued.Module, issued.GameId, issued.Ticket).
+		Find(&tickets).Error; err != nil {
+		return false, err
+	}
+	for _, t := range tickets {
+		if !sameWallet(t.WalletAddress, issued.WalletAddress) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func sameWallet(a, b string) bool {
+	return aptos.SameAddress(a, b) || strings.EqualFold(a, b)
+}
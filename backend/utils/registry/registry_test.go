@@ -0,0 +1,88 @@
+package registry
+
+import (
+	"VirtueGaming/models"
+	"VirtueGaming/utils"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const testModule = "0x1::bingov2"
+
+func newTestRegistry(t *testing.T) (*Registry, *utils.TicketSeeder) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&models.IssuedTicket{}, &models.Ticket{}); err != nil {
+		t.Fatal(err)
+	}
+	seeder := utils.NewTicketSeeder(make([]byte, 32))
+	return New(db, seeder), seeder
+}
+
+func TestIssueAgain(t *testing.T) {
+	r, _ := newTestRegistry(t)
+	first, card, err := r.Issue(utils.NinetyBall, testModule, 1, "0xa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Nonce != 0 || first.Ticket != card.Flatten() {
+		t.Errorf("issued %q at nonce %d, want %q at 0", first.Ticket, first.Nonce, card.Flatten())
+	}
+	again, _, err := r.Issue(utils.NinetyBall, testModule, 1, "0xA")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Ticket != first.Ticket {
+		t.Errorf("issued %q then %q to the same wallet", first.Ticket, again.Ticket)
+	}
+}
+
+// TestIssueTaken gives the first card of a wallet to others, through the
+// registry and through a ticket bought before it, and checks the wallet gets
+// the card of the next nonce.
+func TestIssueTaken(t *testing.T) {
+	r, seeder := newTestRegistry(t)
+	taken := utils.NinetyBall.Generate(seeder.Seed(testModule, 1, "0xa", 0))
+	next := utils.NinetyBall.Generate(seeder.Seed(testModule, 1, "0xa", 1))
+	if err := r.db.Create(&models.IssuedTicket{Module: testModule, GameId: 1, Hash: taken.Hash(), WalletAddress: "0xb", Ticket: taken.Flatten()}).Error; err != nil {
+		t.Fatal(err)
+	}
+	issued, card, err := r.Issue(utils.NinetyBall, testModule, 1, "0xa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if issued.Nonce != 1 || card.Flatten() != next.Flatten() {
+		t.Errorf("issued %q at nonce %d, want %q at 1", card.Flatten(), issued.Nonce, next.Flatten())
+	}
+
+	taken = utils.NinetyBall.Generate(seeder.Seed(testModule, 2, "0xa", 0))
+	if err := r.db.Create(&models.Ticket{Module: testModule, GameId: 2, WalletAddress: "0xc", Ticket: taken.Flatten()}).Error; err != nil {
+		t.Fatal(err)
+	}
+	issued, _, err = r.Issue(utils.NinetyBall, testModule, 2, "0xa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if issued.Nonce != 1 {
+		t.Errorf("issued at nonce %d, want 1", issued.Nonce)
+	}
+}
+
+func TestIssueExhausted(t *testing.T) {
+	r, seeder := newTestRegistry(t)
+	r.MaxAttempts = 1
+	taken := utils.NinetyBall.Generate(seeder.Seed(testModule, 1, "0xa", 0))
+	if err := r.db.Create(&models.IssuedTicket{Module: testModule, GameId: 1, Hash: taken.Hash(), WalletAddress: "0xb", Ticket: taken.Flatten()}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := r.Issue(utils.NinetyBall, testModule, 1, "0xa"); !errors.Is(err, ErrExhausted) {
+		t.Errorf("got %v, want %v", err, ErrExhausted)
+	}
+}
@@ -0,0 +1,232 @@
+package scheduler
+
+import (
+	"VirtueGaming/models"
+	"VirtueGaming/utils/indexer"
+	"VirtueGaming/utils/smartcontract"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultTick         = 5 * time.Second
+	defaultDrawInterval = 30 * time.Second
+	// leaseTTL outlives the wait for a transaction, so a lease does not
+	// expire while its owner is still submitting.
+	leaseTTL = 3 * time.Minute
+)
+
+// Scheduler starts every bingo game once its start timestamp has passed and
+// then draws a number every interval of the game, or DrawInterval for games
+// stored without one, until the game ends. All its state
+// is in Postgres, so it carries on after a restart, and a lease per game makes
+// sure only one replica drives it. Games are driven in parallel, as many at
+// once as the chain client has operators.
+type Scheduler struct {
+	chain        smartcontract.BingoClient
+	db           *gorm.DB
+	owner        string
+	Tick         time.Duration
+	DrawInterval time.Duration
+}
+
+func New(chain smartcontract.BingoClient, db *gorm.DB) *Scheduler {
+	host, _ := os.Hostname()
+	return &Scheduler{
+		chain:        chain,
+		db:           db,
+		owner:        fmt.Sprintf("%s-%d", host, os.Getpid()),
+		Tick:         defaultTick,
+		DrawInterval: defaultDrawInterval,
+	}
+}
+
+// DrawIntervalFromEnv reads DRAW_INTERVAL, a duration such as "30s".
+func DrawIntervalFromEnv() (time.Duration, error) {
+	v := os.Getenv("DRAW_INTERVAL")
+	if v == "" {
+		return defaultDrawInterval, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid DRAW_INTERVAL: %w", err)
+	}
+	return d, nil
+}
+
+// Run drives the games every Tick until ctx is done.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.Tick)
+	defer ticker.Stop()
+	for {
+		s.tick(time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) tick(now time.Time) {
+	if err := s.releaseEnded(); err != nil {
+		logrus.Error("failed to release the leases of ended games: ", err)
+	}
+	var games []models.Game
+	if err := s.db.Model(&models.Game{}).
+		Where("status IN ?", []string{models.GameStatusNotStarted, models.GameStatusStarted}).
+		Find(&games).Error; err != nil {
+		logrus.Error("failed to fetch games to schedule: ", err)
+		return
+	}
+	workers := s.chain.OperatorCount()
+	if workers < 1 {
+		workers = 1
+	}
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	// The tick waits for every game it drives, so the next one cannot pick
+	// up a game this replica is still submitting for.
+	defer wg.Wait()
+	for i := range games {
+		g := &games[i]
+		if !s.due(g, now) {
+			continue
+		}
+		module := s.chain.BingoModule()
+		if g.Module != "" {
+			var err error
+			if module, err = smartcontract.ParseModule(g.Module); err != nil {
+				logrus.Errorf("game %d has an invalid module: %s", g.GameId, err)
+				continue
+			}
+		}
+		ok, err := s.lease(module, g.GameId, now)
+		if err != nil {
+			logrus.Errorf("failed to lease game %d of %s: %s", g.GameId, module.ID(), err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(g *models.Game, module smartcontract.Module) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := s.drive(g, module); err != nil {
+				logrus.Errorf("failed to drive game %d of %s: %s", g.GameId, module.ID(), err)
+			}
+		}(g, module)
+	}
+}
+
+// due reports whether the game has to be started or needs its next number.
+func (s *Scheduler) due(g *models.Game, now time.Time) bool {
+	if g.Status == models.GameStatusNotStarted {
+		start, err := strconv.ParseInt(g.StartTimestamp, 10, 64)
+		return err == nil && now.Unix() >= start
+	}
+	interval := g.Interval
+	if interval <= 0 {
+		interval = int64(s.DrawInterval / time.Second)
+	}
+	return now.Unix() >= g.LastDrawnAt+interval
+}
+
+// lease takes or renews the lease of the game. Another replica's lease is only
+// taken over once it has expired.
+func (s *Scheduler) lease(module smartcontract.Module, gameID int, now time.Time) (bool, error) {
+	res := s.db.Exec(`
+		INSERT INTO game_leases (module, game_id, owner, expires_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (module, game_id) DO UPDATE SET owner = EXCLUDED.owner, expires_at = EXCLUDED.expires_at
+		WHERE game_leases.owner = EXCLUDED.owner OR game_leases.expires_at < ?`,
+		module.ID(), gameID, s.owner, now.Add(leaseTTL), now)
+	return res.RowsAffected == 1, res.Error
+}
+
+// releaseEnded deletes the leases of games that are finished or cancelled,
+// whoever ended them. Games stored without a module belong to the default
+// bingo module.
+func (s *Scheduler) releaseEnded() error {
+	return s.db.Exec(`
+		DELETE FROM game_leases WHERE EXISTS (
+			SELECT 1 FROM games
+			WHERE games.game_id = game_leases.game_id
+			AND (games.module = game_leases.module OR (games.module = '' AND game_leases.module = ?))
+			AND games.status IN ?)`,
+		s.chain.BingoModule().ID(), []string{models.GameStatusFinished, models.GameStatusCancelled}).Error
+}
+
+func (s *Scheduler) drive(g *models.Game, module smartcontract.Module) error {
+	if g.Status == models.GameStatusNotStarted {
+		return s.start(g, module)
+	}
+	return s.draw(g, module)
+}
+
+func (s *Scheduler) start(g *models.Game, module smartcontract.Module) error {
+	tx, err := s.chain.StartGame(smartcontract.StartGameParams{Module: module, GameID: g.GameId})
+	switch {
+	case errors.Is(err, smartcontract.ErrCantStartGameYet):
+		return nil
+	case errors.Is(err, smartcontract.ErrGameHasStarted):
+		return s.update(g, map[string]interface{}{"status": models.GameStatusStarted})
+	case err != nil:
+		return err
+	}
+	started := map[string]interface{}{"status": models.GameStatusStarted, "last_drawn_at": time.Now().Unix()}
+	var e indexer.GameEvent
+	if found, _ := tx.Event(module.EventType("GameStartedEvent"), &e); found {
+		started["last_drawn_at"] = e.Timestamp
+	}
+	logrus.Infof("started game %d of %s in %s", g.GameId, module.ID(), tx.Result.TransactionHash)
+	return s.update(g, started)
+}
+
+func (s *Scheduler) draw(g *models.Game, module smartcontract.Module) error {
+	tx, err := s.chain.DrawNumber(smartcontract.DrawNumberParams{Module: module, GameID: g.GameId})
+	switch {
+	case errors.Is(err, smartcontract.ErrNeedToWaitIntervalTime):
+		return nil
+	case errors.Is(err, smartcontract.ErrGameHasEnded):
+		return s.update(g, map[string]interface{}{"status": models.GameStatusFinished})
+	case err != nil:
+		return err
+	}
+	txHash := tx.Result.TransactionHash
+	drawn := map[string]interface{}{"last_drawn_at": time.Now().Unix()}
+	var e indexer.DrawNumberEvent
+	if found, _ := tx.Event(module.EventType("DrawNumberEvent"), &e); found {
+		drawn["last_drawn_at"] = e.Timestamp
+		number := models.DrawnNumber{GameId: g.GameId, Module: module.ID(), Number: e.Number}
+		if err := s.db.Model(&models.DrawnNumber{}).
+			Where(map[string]interface{}{"game_id": number.GameId, "module": number.Module, "number": number.Number}).
+			Assign(models.DrawnNumber{TransactionHash: txHash}).
+			FirstOrCreate(&number).Error; err != nil {
+			return err
+		}
+		logrus.Infof("drew %d for game %d of %s in %s", e.Number, g.GameId, module.ID(), txHash)
+	}
+	if ended, _ := tx.Event(module.EventType("GameEndedEvent"), &struct{}{}); ended {
+		drawn["status"] = models.GameStatusFinished
+		logrus.Infof("game %d of %s ended in %s", g.GameId, module.ID(), txHash)
+	}
+	return s.update(g, drawn)
+}
+
+func (s *Scheduler) update(g *models.Game, values map[string]interface{}) error {
+	return s.db.Model(&models.Game{}).
+		Where("game_id = ? AND module = ?", g.GameId, g.Module).
+		Updates(values).Error
+}
@@ -0,0 +1,187 @@
+package scheduler
+
+import (
+	"VirtueGaming/models"
+	"VirtueGaming/utils/smartcontract"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const testInterval = 10
+
+type testScheduler struct {
+	*Scheduler
+	t     *testing.T
+	chain *smartcontract.FakeChain
+	now   time.Time
+}
+
+func newTestScheduler(t *testing.T) *testScheduler {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&models.Game{}, &models.DrawnNumber{}, &models.GameLease{}); err != nil {
+		t.Fatal(err)
+	}
+	s := &testScheduler{t: t, chain: smartcontract.NewFakeChain("0xad"), now: time.Now()}
+	s.chain.Now = func() time.Time { return s.now }
+	s.chain.Seed(1)
+	s.Scheduler = New(s.chain, db)
+	return s
+}
+
+// createGame creates a game starting in a minute, on chain and in the
+// database.
+func (s *testScheduler) createGame() models.Game {
+	s.t.Helper()
+	start := strconv.FormatInt(s.now.Unix()+60, 10)
+	if _, err := s.chain.CreateGame(smartcontract.CreateGameParams{
+		GameName:       "Friday bingo",
+		StartTimestamp: start,
+		MintPrice:      100000000,
+		Interval:       testInterval,
+		CollectionName: "Friday bingo",
+	}); err != nil {
+		s.t.Fatal(err)
+	}
+	g := models.Game{
+		GameId:         0,
+		Module:         s.chain.BingoModule().ID(),
+		StartTimestamp: start,
+		Status:         models.GameStatusNotStarted,
+		Interval:       testInterval,
+	}
+	if err := s.db.Create(&g).Error; err != nil {
+		s.t.Fatal(err)
+	}
+	return g
+}
+
+func (s *testScheduler) game() models.Game {
+	s.t.Helper()
+	var g models.Game
+	if err := s.db.Where("game_id = ?", 0).First(&g).Error; err != nil {
+		s.t.Fatal(err)
+	}
+	return g
+}
+
+func (s *testScheduler) advance(d time.Duration) {
+	s.now = s.now.Add(d)
+	s.tick(s.now)
+}
+
+func TestDue(t *testing.T) {
+	s := New(nil, nil)
+	s.DrawInterval = 30 * time.Second
+	now := time.Unix(1000, 0)
+	tests := []struct {
+		name string
+		game models.Game
+		want bool
+	}{
+		{"before the start", models.Game{Status: models.GameStatusNotStarted, StartTimestamp: "1001"}, false},
+		{"at the start", models.Game{Status: models.GameStatusNotStarted, StartTimestamp: "1000"}, true},
+		{"invalid start", models.Game{Status: models.GameStatusNotStarted, StartTimestamp: "soon"}, false},
+		{"within the interval", models.Game{Status: models.GameStatusStarted, Interval: 10, LastDrawnAt: 991}, false},
+		{"after the interval", models.Game{Status: models.GameStatusStarted, Interval: 10, LastDrawnAt: 990}, true},
+		{"default interval", models.Game{Status: models.GameStatusStarted, LastDrawnAt: 980}, false},
+		{"after the default interval", models.Game{Status: models.GameStatusStarted, LastDrawnAt: 970}, true},
+	}
+	for _, tt := range tests {
+		if got := s.due(&tt.game, now); got != tt.want {
+			t.Errorf("%s: due %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLease(t *testing.T) {
+	a := newTestScheduler(t)
+	b := New(a.chain, a.db)
+	b.owner = "other"
+	module := a.chain.BingoModule()
+	lease := func(s *Scheduler, now time.Time, want bool) {
+		t.Helper()
+		ok, err := s.lease(module, 0, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != want {
+			t.Errorf("lease of %s: got %v, want %v", s.owner, ok, want)
+		}
+	}
+	lease(a.Scheduler, a.now, true)
+	lease(b, a.now, false)
+	// The owner renews its lease, another replica takes it once it expired.
+	lease(a.Scheduler, a.now.Add(time.Minute), true)
+	lease(b, a.now.Add(time.Minute+leaseTTL+time.Second), true)
+	lease(a.Scheduler, a.now.Add(time.Minute+leaseTTL+2*time.Second), false)
+}
+
+func TestTick(t *testing.T) {
+	s := newTestScheduler(t)
+	s.createGame()
+
+	s.advance(30 * time.Second)
+	if g := s.game(); g.Status != models.GameStatusNotStarted {
+		t.Fatalf("game %q before its start", g.Status)
+	}
+	s.advance(30 * time.Second)
+	started := s.game()
+	if started.Status != models.GameStatusStarted || started.LastDrawnAt != s.now.Unix() {
+		t.Fatalf("game %q, last drawn at %d, want started at %d", started.Status, started.LastDrawnAt, s.now.Unix())
+	}
+
+	s.advance(testInterval / 2 * time.Second)
+	var drawn int64
+	s.db.Model(&models.DrawnNumber{}).Count(&drawn)
+	if drawn != 0 {
+		t.Errorf("drew %d numbers within the interval", drawn)
+	}
+	s.advance(testInterval / 2 * time.Second)
+	s.db.Model(&models.DrawnNumber{}).Count(&drawn)
+	if drawn != 1 {
+		t.Errorf("drew %d numbers, want 1", drawn)
+	}
+	if g := s.game(); g.LastDrawnAt != s.now.Unix() {
+		t.Errorf("last drawn at %d, want %d", g.LastDrawnAt, s.now.Unix())
+	}
+}
+
+// TestTickEnded checks that a game ended behind the back of the scheduler is
+// marked finished instead of drawn for again, and that its lease is released.
+func TestTickEnded(t *testing.T) {
+	s := newTestScheduler(t)
+	s.createGame()
+	s.advance(time.Minute)
+	if g := s.game(); g.Status != models.GameStatusStarted {
+		t.Fatalf("game %q, want %q", g.Status, models.GameStatusStarted)
+	}
+	for {
+		s.now = s.now.Add(testInterval * time.Second)
+		tx, err := s.chain.DrawNumber(smartcontract.DrawNumberParams{GameID: 0})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ended, _ := tx.Event(s.chain.BingoModule().EventType("GameEndedEvent"), &struct{}{}); ended {
+			break
+		}
+	}
+	s.advance(testInterval * time.Second)
+	if g := s.game(); g.Status != models.GameStatusFinished {
+		t.Errorf("game %q, want %q", g.Status, models.GameStatusFinished)
+	}
+	s.advance(testInterval * time.Second)
+	var leases int64
+	s.db.Model(&models.GameLease{}).Count(&leases)
+	if leases != 0 {
+		t.Errorf("got %d leases, want 0", leases)
+	}
+}
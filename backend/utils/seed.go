@@ -0,0 +1,172 @@
+package utils
+
+import (
+	"VirtueGaming/utils/aptos"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var ErrSeedCommitmentMismatch = errors.New("game secret does not match its commitment")
+
+// TicketSeed is what a ticket is generated from, see GenerateFromSeed.
+type TicketSeed [32]byte
+
+func RandomTicketSeed() TicketSeed {
+	var seed TicketSeed
+	rand.Read(seed[:])
+	return seed
+}
+
+// NewTicketSeed derives the seed of the ticket wallet gets in a game from the
+// secret of the game. nonce counts the tickets that were drawn again because
+// another wallet already had the same one, see registry.Registry.
+func NewTicketSeed(gameSecret []byte, module string, gameID int, wallet string, nonce int) TicketSeed {
+	mac := hmac.New(sha256.New, gameSecret)
+	fmt.Fprintf(mac, "ticket|%s|%d|%s", module, gameID, normalizeWallet(wallet))
+	if nonce != 0 {
+		fmt.Fprintf(mac, "|%d", nonce)
+	}
+	var seed TicketSeed
+	copy(seed[:], mac.Sum(nil))
+	return seed
+}
+
+// SeedCommitment is the hex encoded sha256 of the secret of a game. It is
+// published when the game is created, the secret once the game is over.
+func SeedCommitment(gameSecret []byte) string {
+	h := sha256.Sum256(gameSecret)
+	return hex.EncodeToString(h[:])
+}
+
+// VerifyTicket recomputes the card of wallet, of format f, from the published
+// secret of a game, hex encoded, and the nonce in the metadata of the card. It
+// checks the secret against the commitment published before the game and the
+// card against card.
+func VerifyTicket(f CardFormat, gameSecret, commitment, module string, gameID int, wallet string, nonce int, card Card) error {
+	secret, err := hex.DecodeString(strings.TrimPrefix(gameSecret, "0x"))
+	if err != nil {
+		return fmt.Errorf("invalid game secret: %w", err)
+	}
+	if !strings.EqualFold(SeedCommitment(secret), strings.TrimPrefix(commitment, "0x")) {
+		return ErrSeedCommitmentMismatch
+	}
+	if want := f.Generate(NewTicketSeed(secret, module, gameID, wallet, nonce)); want.Flatten() != card.Flatten() {
+		return fmt.Errorf("ticket does not match its seed, want %s", want.Flatten())
+	}
+	return nil
+}
+
+// TicketSeeder derives the secret of every game from one master secret, so
+// nothing but the master secret has to be kept.
+type TicketSeeder struct {
+	master []byte
+}
+
+func NewTicketSeeder(master []byte) *TicketSeeder {
+	return &TicketSeeder{master: master}
+}
+
+// NewTicketSeederFromEnv reads TICKET_SEED_SECRET. Changing it changes the
+// secrets of the games already created, so their commitments no longer hold.
+func NewTicketSeederFromEnv() (*TicketSeeder, error) {
+	master := os.Getenv("TICKET_SEED_SECRET")
+	if len(master) < 32 {
+		return nil, errors.New("TICKET_SEED_SECRET must be at least 32 characters")
+	}
+	return NewTicketSeeder([]byte(master)), nil
+}
+
+// GameSecret returns the secret the tickets of a game are seeded with. It must
+// not be published before the game is over.
+func (s *TicketSeeder) GameSecret(module string, gameID int) []byte {
+	mac := hmac.New(sha256.New, s.master)
+	fmt.Fprintf(mac, "game|%s|%d", module, gameID)
+	return mac.Sum(nil)
+}
+
+func (s *TicketSeeder) Commitment(module string, gameID int) string {
+	return SeedCommitment(s.GameSecret(module, gameID))
+}
+
+// Seed returns the seed of the ticket of wallet in a game, see NewTicketSeed.
+func (s *TicketSeeder) Seed(module string, gameID int, wallet string, nonce int) TicketSeed {
+	return NewTicketSeed(s.GameSecret(module, gameID), module, gameID, wallet, nonce)
+}
+
+// normalizeWallet makes the short and long form of an address seed the same
+// ticket.
+func normalizeWallet(wallet string) string {
+	if addr, err := aptos.ParseAddress(wallet); err == nil {
+		return addr.String()
+	}
+	return strings.ToLower(wallet)
+}
+
+// seedStream draws the random choices of a card from its seed, in a way that
+// anyone verifying a card can repeat:
+//
+//   - block i of the stream is SHA-256(seed || i), i a big endian uint64
+//     counting from 0, read as 4 big endian uint64 values in turn;
+//   - Int64N(n) takes the next value v, draws again while v >= 2^64 - 2^64 % n
+//     and returns v % n, so every result is equally likely;
+//   - Perm(n) starts from 0, 1, ..., n-1 and, for i from n-1 down to 1, swaps
+//     element i with element Int64N(i+1).
+type seedStream struct {
+	seed    TicketSeed
+	counter uint64
+	block   [sha256.Size]byte
+	next    int
+}
+
+func newSeedStream(seed TicketSeed) *seedStream {
+	return &seedStream{seed: seed, next: sha256.Size}
+}
+
+func (s *seedStream) uint64() uint64 {
+	if s.next == sha256.Size {
+		var msg [len(s.seed) + 8]byte
+		copy(msg[:], s.seed[:])
+		binary.BigEndian.PutUint64(msg[len(s.seed):], s.counter)
+		s.block = sha256.Sum256(msg[:])
+		s.counter++
+		s.next = 0
+	}
+	v := binary.BigEndian.Uint64(s.block[s.next:])
+	s.next += 8
+	return v
+}
+
+// Int64N returns a number from 0 to n-1. It panics if n <= 0.
+func (s *seedStream) Int64N(n int64) int64 {
+	if n <= 0 {
+		panic("invalid argument to Int64N")
+	}
+	// -un % un is 2^64 % un in uint64 arithmetic.
+	un := uint64(n)
+	limit := -(-un % un)
+	for {
+		if v := s.uint64(); limit == 0 || v < limit {
+			return int64(v % un)
+		}
+	}
+}
+
+// Perm returns a permutation of the numbers from 0 to n-1.
+func (s *seedStream) Perm(n int) []int {
+	p := make([]int, n)
+	for i := range p {
+		p[i] = i
+	}
+	for i := n - 1; i > 0; i-- {
+		j := s.Int64N(int64(i + 1))
+		p[i], p[j] = p[j], p[i]
+	}
+	return p
+}
@@ -0,0 +1,60 @@
+package smartcontract
+
+// BingoClient covers the entry and view functions of the bingo module.
+type BingoClient interface {
+	// BingoModule is the module new bingo games are created on.
+	BingoModule() Module
+	CreateGame(p CreateGameParams) (*TxResult, error)
+	// PrepareJoinGame builds join_game for the player to sign, the card is
+	// bought by and for the player's account. SubmitSigned sends the calls
+	// of every Prepare function once signed.
+	PrepareJoinGame(p JoinGameParams, sponsored bool) (*PlayerTx, error)
+	SubmitSigned(t *PlayerTx, publicKey, signature string) (*TxResult, error)
+	StartGame(p StartGameParams) (*TxResult, error)
+	DrawNumber(p DrawNumberParams) (*TxResult, error)
+	// OperatorCount is the number of accounts StartGame and DrawNumber are
+	// spread over.
+	OperatorCount() int
+	// PrepareClaimPrize builds claim_prize for the player to sign.
+	PrepareClaimPrize(p ClaimPrizeParams) (*PlayerTx, error)
+	// CardOwner returns the account owning a card of a game.
+	CardOwner(p CardParams) (string, error)
+	CancelGame(p CancelGameParams) (*TxResult, error)
+	BingoGameState(p GameStateParams) (*GameState, error)
+}
+
+// SnlClient covers the entry and view functions of the snakes and ladders
+// module.
+type SnlClient interface {
+	// SnlModule is the module new snakes and ladders games are created on.
+	SnlModule() Module
+	SnlCreateGame(p CreateGameParams) (*TxResult, error)
+	// PrepareSnlJoinGame builds join_game for the player to sign, see
+	// BingoClient.PrepareJoinGame.
+	PrepareSnlJoinGame(p SnlJoinGameParams) (*PlayerTx, error)
+	SubmitSigned(t *PlayerTx, publicKey, signature string) (*TxResult, error)
+	SnlStartGame(p StartGameParams) (*TxResult, error)
+	// PrepareSnlRollDice builds roll_dice for the owner of the avatar to
+	// sign.
+	PrepareSnlRollDice(p SnlRollDiceParams) (*PlayerTx, error)
+	SnlGameWon(p SnlGameWonParams) (*TxResult, error)
+	// AvatarOwner returns the account owning an avatar of a game.
+	AvatarOwner(p CardParams) (string, error)
+	SnlCancelGame(p CancelGameParams) (*TxResult, error)
+	SnlGameState(p GameStateParams) (*GameState, error)
+}
+
+// ChainClient is what the API handlers depend on. AptosClient talks to a
+// real node, FakeChain keeps the game state in memory.
+type ChainClient interface {
+	BingoClient
+	SnlClient
+	// QueueDepth is the number of calls waiting to be submitted.
+	QueueDepth() int
+	Operators() ([]Operator, error)
+}
+
+var (
+	_ ChainClient = (*AptosClient)(nil)
+	_ ChainClient = (*FakeChain)(nil)
+)
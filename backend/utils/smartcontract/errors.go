@@ -0,0 +1,161 @@
+package smartcontract
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+)
+
+// Sentinel errors for the ERROR_* constants of bingov2.move and SNL.move.
+// Both modules share most names, so a single sentinel covers both.
+var (
+	ErrSignerNotAdmin         = errors.New("signer is not admin")
+	ErrInvalidStartTimestamp  = errors.New("invalid start timestamp")
+	ErrInsufficientBalance    = errors.New("insufficient balance")
+	ErrGameNotInitialized     = errors.New("game not initialized")
+	ErrGameHasStarted         = errors.New("game has started")
+	ErrGameNotStarted         = errors.New("game not started")
+	ErrCantStartGameYet       = errors.New("can't start game yet")
+	ErrInvalidPrizeName       = errors.New("invalid prize name")
+	ErrGameHasEnded           = errors.New("game has ended")
+	ErrNotWinningTicket       = errors.New("not a winning ticket")
+	ErrNeedToWaitIntervalTime = errors.New("need to wait interval time")
+	ErrDuplicatedTicket       = errors.New("duplicated ticket")
+	ErrUserAlreadyBoughtOne   = errors.New("user already bought one")
+	ErrNotGameCreator         = errors.New("not game creator")
+	ErrNotAvatarOwner         = errors.New("not avatar owner")
+	ErrOther                  = errors.New("other contract error")
+	ErrTransactionFailed      = errors.New("transaction failed")
+	ErrUnknownAbort           = errors.New("unknown move abort")
+)
+
+var abortReasons = map[string]error{
+	"ERROR_SIGNER_NOT_ADMIN":           ErrSignerNotAdmin,
+	"ERROR_INVALID_START_TIMESTAMP":    ErrInvalidStartTimestamp,
+	"ERROR_INSUFFICIENT_BALANCE":       ErrInsufficientBalance,
+	"ERROR_GAME_NOT_INITIALIZED":       ErrGameNotInitialized,
+	"ERROR_GAME_HAS_STARTED":           ErrGameHasStarted,
+	"ERROR_GAME_NOT_STARTED":           ErrGameNotStarted,
+	"ERROR_CANT_START_GAME_YET":        ErrCantStartGameYet,
+	"ERROR_INVALID_PRIZE_NAME":         ErrInvalidPrizeName,
+	"ERROR_GAME_HAS_ENDED":             ErrGameHasEnded,
+	"ERROR_NOT_WINNING_TICKET":         ErrNotWinningTicket,
+	"ERROR_NEED_TO_WAIT_INTERVAL_TIME": ErrNeedToWaitIntervalTime,
+	"ERROR_DUPLICATED_TICKET":          ErrDuplicatedTicket,
+	"ERROR_USER_ALREADY_BOUGHT_ONE":    ErrUserAlreadyBoughtOne,
+	"ERROR_NOT_GAME_CREATOR":           ErrNotGameCreator,
+	"ERROR_NOT_AVATAR_OWNER":           ErrNotAvatarOwner,
+	"ERROR_OTHER":                      ErrOther,
+}
+
+// bingoAbortCodes and snlAbortCodes list the ERROR_* constants by value.
+var bingoAbortCodes = []string{
+	"ERROR_SIGNER_NOT_ADMIN",
+	"ERROR_INVALID_START_TIMESTAMP",
+	"ERROR_INSUFFICIENT_BALANCE",
+	"ERROR_GAME_NOT_INITIALIZED",
+	"ERROR_GAME_HAS_STARTED",
+	"ERROR_GAME_NOT_STARTED",
+	"ERROR_CANT_START_GAME_YET",
+	"ERROR_INVALID_PRIZE_NAME",
+	"ERROR_GAME_HAS_ENDED",
+	"ERROR_NOT_WINNING_TICKET",
+	"ERROR_NEED_TO_WAIT_INTERVAL_TIME",
+	"ERROR_DUPLICATED_TICKET",
+	"ERROR_USER_ALREADY_BOUGHT_ONE",
+	"ERROR_NOT_GAME_CREATOR",
+	"ERROR_OTHER",
+}
+
+var snlAbortCodes = []string{
+	"ERROR_SIGNER_NOT_ADMIN",
+	"ERROR_INVALID_START_TIMESTAMP",
+	"ERROR_INSUFFICIENT_BALANCE",
+	"ERROR_GAME_NOT_INITIALIZED",
+	"ERROR_GAME_HAS_STARTED",
+	"ERROR_GAME_NOT_STARTED",
+	"ERROR_CANT_START_GAME_YET",
+	"ERROR_GAME_HAS_ENDED",
+	"ERROR_NEED_TO_WAIT_INTERVAL_TIME",
+	"ERROR_USER_ALREADY_BOUGHT_ONE",
+	"ERROR_NOT_GAME_CREATOR",
+	"ERROR_NOT_AVATAR_OWNER",
+	"ERROR_OTHER",
+}
+
+// abortCodes resolves the abort code of a module when the node did not attach
+// the constant name to vm_status. Configured module names are added by
+// registerAbortCodes.
+var (
+	abortCodesMu sync.RWMutex
+	abortCodes   = map[string][]string{
+		defaultBingoModuleName: bingoAbortCodes,
+		defaultSnlModuleName:   snlAbortCodes,
+	}
+)
+
+func registerAbortCodes(module string, codes []string) {
+	abortCodesMu.Lock()
+	defer abortCodesMu.Unlock()
+	abortCodes[module] = codes
+}
+
+func lookupAbortCode(module string, code int) string {
+	abortCodesMu.RLock()
+	defer abortCodesMu.RUnlock()
+	if names, ok := abortCodes[module]; ok && code < len(names) {
+		return names[code]
+	}
+	return ""
+}
+
+// abortPattern matches both "Move abort in 0x1::bingov2: ERROR_GAME_HAS_STARTED(0x4): "
+// and the nameless "Move abort in 0x1::bingov2: 0x4" form.
+var abortPattern = regexp.MustCompile(`Move abort in (0x[0-9a-fA-F]+)::(\w+): (?:(\w+)\()?0x([0-9a-fA-F]+)`)
+
+// AbortError is a Move abort raised by one of the game modules. It unwraps to
+// the matching sentinel error.
+type AbortError struct {
+	Module   string
+	Reason   string
+	Code     int
+	VMStatus string
+	err      error
+}
+
+func (e *AbortError) Error() string {
+	return fmt.Sprintf("%s::%s(%d): %s", e.Module, e.Reason, e.Code, e.err)
+}
+
+func (e *AbortError) Unwrap() error {
+	return e.err
+}
+
+// ParseAbort decodes a vm_status. It returns nil when vmStatus is not a Move
+// abort.
+func ParseAbort(vmStatus string) *AbortError {
+	m := abortPattern.FindStringSubmatch(vmStatus)
+	if m == nil {
+		return nil
+	}
+	code, _ := strconv.ParseInt(m[4], 16, 64)
+	abort := &AbortError{Module: m[2], Reason: m[3], Code: int(code), VMStatus: vmStatus}
+	if abort.Reason == "" {
+		abort.Reason = lookupAbortCode(abort.Module, abort.Code)
+	}
+	var ok bool
+	if abort.err, ok = abortReasons[abort.Reason]; !ok {
+		abort.err = ErrUnknownAbort
+	}
+	return abort
+}
+
+// txError describes why a committed transaction failed.
+func txError(r *TxResult) error {
+	if abort := ParseAbort(r.Result.VMStatus); abort != nil {
+		return abort
+	}
+	return fmt.Errorf("%w: %s %s", ErrTransactionFailed, r.Result.TransactionHash, r.Result.VMStatus)
+}
@@ -0,0 +1,60 @@
+package smartcontract
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseAbort(t *testing.T) {
+	registerAbortCodes("bingo_custom", bingoAbortCodes)
+	tests := []struct {
+		name     string
+		vmStatus string
+		module   string
+		reason   string
+		code     int
+		want     error
+	}{
+		{"named", "Move abort in 0x1::bingov2: ERROR_GAME_HAS_STARTED(0x4): ", "bingov2", "ERROR_GAME_HAS_STARTED", 4, ErrGameHasStarted},
+		{"named snl", "Move abort in 0x1::SNL: ERROR_NOT_AVATAR_OWNER(0xb): ", "SNL", "ERROR_NOT_AVATAR_OWNER", 11, ErrNotAvatarOwner},
+		{"long address", "Move abort in 0x000000000000000000000000000000000000000000000000000000000000beef::SNL: ERROR_OTHER(0xc): ", "SNL", "ERROR_OTHER", 12, ErrOther},
+		{"nameless bingo", "Move abort in 0x1::bingov2: 0x9", "bingov2", "ERROR_NOT_WINNING_TICKET", 9, ErrNotWinningTicket},
+		// The codes of the two modules differ past ERROR_CANT_START_GAME_YET.
+		{"nameless snl", "Move abort in 0x1::SNL: 0x9", "SNL", "ERROR_USER_ALREADY_BOUGHT_ONE", 9, ErrUserAlreadyBoughtOne},
+		{"upper case hex", "Move abort in 0xABC::bingov2: 0xD", "bingov2", "ERROR_NOT_GAME_CREATOR", 13, ErrNotGameCreator},
+		{"configured module", "Move abort in 0x1::bingo_custom: 0x2", "bingo_custom", "ERROR_INSUFFICIENT_BALANCE", 2, ErrInsufficientBalance},
+		{"unknown module", "Move abort in 0x1::coin: 0x10006", "coin", "", 0x10006, ErrUnknownAbort},
+		{"code out of range", "Move abort in 0x1::SNL: 0x63", "SNL", "", 99, ErrUnknownAbort},
+		{"unknown name", "Move abort in 0x1::bingov2: ERROR_NEW(0x63): ", "bingov2", "ERROR_NEW", 99, ErrUnknownAbort},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			abort := ParseAbort(tt.vmStatus)
+			if abort == nil {
+				t.Fatalf("%q not decoded as a Move abort", tt.vmStatus)
+			}
+			if abort.Module != tt.module || abort.Reason != tt.reason || abort.Code != tt.code {
+				t.Errorf("got %s::%s(%d), want %s::%s(%d)", abort.Module, abort.Reason, abort.Code, tt.module, tt.reason, tt.code)
+			}
+			if !errors.Is(abort, tt.want) {
+				t.Errorf("got %v, want %v", abort, tt.want)
+			}
+			if abort.VMStatus != tt.vmStatus {
+				t.Errorf("vm status %q, want %q", abort.VMStatus, tt.vmStatus)
+			}
+		})
+	}
+}
+
+func TestParseAbortNotAbort(t *testing.T) {
+	for _, vmStatus := range []string{
+		"",
+		"Executed successfully",
+		"Out of gas",
+		"Execution failed in 0x1::coin::transfer at code offset 4",
+	} {
+		if abort := ParseAbort(vmStatus); abort != nil {
+			t.Errorf("%q decoded as %v", vmStatus, abort)
+		}
+	}
+}
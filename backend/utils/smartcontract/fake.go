@@ -0,0 +1,896 @@
+package smartcontract
+
+import (
+	"VirtueGaming/utils/aptos"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// prizePool mirrors PRIZE_POOL of bingov2.move: top line, middle line,
+// bottom line, full house and treasury, in percent.
+var prizePool = [5]uint64{18, 18, 18, 36, 10}
+
+// FakeBingoGame is the in-memory counterpart of the Game struct of
+// bingov2.move.
+type FakeBingoGame struct {
+	Creator           string
+	Name              string
+	CollectionAddress string
+	MintPrice         uint64
+	Interval          int64
+	Cards             [][][]int
+	CardAddresses     []string
+	Buyers            []string
+	StartLastDrawnAt  int64
+	UndrawnNumbers    []int
+	DrawnNumbers      []int
+	IsStarted         bool
+	IsFinished        bool
+	ClaimPending      FakeClaims
+}
+
+type FakeClaims struct {
+	Pendings     int
+	Row0         []string
+	Row1         []string
+	Row2         []string
+	FullHouse    []string
+	WinningCards []string
+}
+
+// FakeSnlGame is the in-memory counterpart of the Game struct of SNL.move.
+type FakeSnlGame struct {
+	Creator           string
+	Name              string
+	CollectionAddress string
+	MintPrice         uint64
+	Interval          int64
+	Buyers            []string
+	Avatars           []string
+	StartTimestamp    int64
+	IsStarted         bool
+	IsFinished        bool
+	Winner            string
+	lastRoll          map[string]int64
+}
+
+// FakeChain implements ChainClient in memory following the state rules of
+// bingov2.move and SNL.move. The calls the server sends are signed by Admin,
+// the ones of a PlayerTx by its Sender, and aborts are reported with the same
+// vm_status format as a real node.
+type FakeChain struct {
+	mu sync.Mutex
+
+	Admin    string
+	Treasury string
+	Now      func() time.Time
+	Balances map[string]uint64
+
+	games    []*FakeBingoGame
+	snlGames []*FakeSnlGame
+	owners   map[string]string
+	version  int64
+	rand     *rand.Rand
+}
+
+func NewFakeChain(admin string) *FakeChain {
+	return &FakeChain{
+		Admin:    admin,
+		Treasury: admin,
+		Now:      time.Now,
+		Balances: make(map[string]uint64),
+		owners:   make(map[string]string),
+		rand:     rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Seed makes the draws and addresses of f repeat from one run to the next.
+func (f *FakeChain) Seed(seed int64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rand = rand.New(rand.NewSource(seed))
+}
+
+// Fund credits amount octas to addr.
+func (f *FakeChain) Fund(addr string, amount uint64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Balances[addr] += amount
+}
+
+// BingoGame returns a copy of the state of a bingo game.
+func (f *FakeChain) BingoGame(gameID int) (FakeBingoGame, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if gameID < 0 || gameID >= len(f.games) {
+		return FakeBingoGame{}, false
+	}
+	return *f.games[gameID], true
+}
+
+// SnlGame returns a copy of the state of a snakes and ladders game.
+func (f *FakeChain) SnlGame(gameID int) (FakeSnlGame, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if gameID < 0 || gameID >= len(f.snlGames) {
+		return FakeSnlGame{}, false
+	}
+	return *f.snlGames[gameID], true
+}
+
+// fakeModuleAddress is where the fake modules live, in the long form
+// ParseModule and moduleFromEnv normalize addresses to.
+const fakeModuleAddress = "0x0000000000000000000000000000000000000000000000000000000000000001"
+
+func (f *FakeChain) BingoModule() Module {
+	return Module{Address: fakeModuleAddress, Name: defaultBingoModuleName}
+}
+
+func (f *FakeChain) SnlModule() Module {
+	return Module{Address: fakeModuleAddress, Name: defaultSnlModuleName}
+}
+
+func (f *FakeChain) now() int64 {
+	return f.Now().Unix()
+}
+
+func (f *FakeChain) newAddress() string {
+	b := make([]byte, 32)
+	f.rand.Read(b)
+	return "0x" + hex.EncodeToString(b)
+}
+
+// playerTx builds the transaction a Prepare method returns for sender to
+// sign. Its bytes are random, SubmitSigned does not check the signature.
+func (f *FakeChain) playerTx(call Call, sender string) *PlayerTx {
+	raw := make([]byte, 64)
+	f.rand.Read(raw)
+	return &PlayerTx{
+		Call:           call,
+		Sender:         sender,
+		RawTransaction: "0x" + hex.EncodeToString(raw),
+		SigningMessage: hex.EncodeToString(raw),
+		ExpiresAt:      f.Now().Add(2 * time.Minute),
+	}
+}
+
+// objectTransfer builds the event 0x1::object emits when object changes owner.
+func (f *FakeChain) objectTransfer(object, from, to string) Event {
+	raw, _ := json.Marshal(map[string]string{"object": object, "from": from, "to": to})
+	return Event{Type: objectTransferEvents[0], Data: raw}
+}
+
+// event builds an event the way the node renders it: every field, u64
+// included, is a JSON string.
+func (f *FakeChain) event(module Module, name string, fields map[string]interface{}) Event {
+	data := make(map[string]string, len(fields))
+	for k, v := range fields {
+		data[k] = fmt.Sprint(v)
+	}
+	raw, _ := json.Marshal(data)
+	return Event{Type: module.EventType(name), Data: raw}
+}
+
+func (f *FakeChain) success(events ...Event) (*TxResult, error) {
+	f.version++
+	return &TxResult{Result: Result{
+		TransactionHash: f.newAddress(),
+		Sender:          f.Admin,
+		Success:         true,
+		TimestampUs:     f.Now().UnixMicro(),
+		Version:         f.version,
+		VMStatus:        "Executed successfully",
+		Events:          events,
+	}}, nil
+}
+
+func (f *FakeChain) abort(module Module, reason string, code int) (*TxResult, error) {
+	f.version++
+	tx := &TxResult{Result: Result{
+		TransactionHash: f.newAddress(),
+		Sender:          f.Admin,
+		Success:         false,
+		TimestampUs:     f.Now().UnixMicro(),
+		Version:         f.version,
+		VMStatus:        fmt.Sprintf("Move abort in %s: %s(0x%x): ", module.ID(), reason, code),
+	}}
+	return tx, txError(tx)
+}
+
+// gameEvent builds the events that only carry game_id and timestamp.
+func (f *FakeChain) gameEvent(module Module, name string, gameID int) Event {
+	return f.event(module, name, map[string]interface{}{"game_id": gameID, "timestamp": f.now()})
+}
+
+func (f *FakeChain) transfer(from, to string, amount uint64) {
+	f.Balances[from] -= amount
+	f.Balances[to] += amount
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// bingo module
+
+func (f *FakeChain) bingoAbort(reason string, code int) (*TxResult, error) {
+	return f.abort(f.BingoModule(), reason, code)
+}
+
+func (f *FakeChain) bingoGame(gameID int) *FakeBingoGame {
+	if gameID < 0 || gameID >= len(f.games) {
+		return nil
+	}
+	return f.games[gameID]
+}
+
+// treasury returns the resource account that holds the pool of a game.
+func bingoTreasury(gameID int) string {
+	return "Bingo#" + strconv.Itoa(gameID)
+}
+
+func (f *FakeChain) CreateGame(p CreateGameParams) (*TxResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	start, _ := strconv.ParseInt(p.StartTimestamp, 10, 64)
+	if start < f.now() {
+		return f.bingoAbort("ERROR_INVALID_START_TIMESTAMP", 1)
+	}
+	undrawn := make([]int, 90)
+	for i := range undrawn {
+		undrawn[i] = i + 1
+	}
+	f.games = append(f.games, &FakeBingoGame{
+		Creator:           f.Admin,
+		Name:              p.GameName,
+		MintPrice:         p.MintPrice,
+		Interval:          int64(p.Interval),
+		CollectionAddress: f.newAddress(),
+		StartLastDrawnAt:  start,
+		UndrawnNumbers:    undrawn,
+	})
+	return f.success(f.event(f.BingoModule(), "CreateGameEvent", map[string]interface{}{
+		"creator":         f.Admin,
+		"game_name":       p.GameName,
+		"game_id":         len(f.games) - 1,
+		"start_timestamp": start,
+		"timestamp":       f.now(),
+	}))
+}
+
+// PrepareJoinGame checks the call against the game state. The fake does not
+// charge gas, so the transaction costs nothing and its raw bytes are empty.
+func (f *FakeChain) PrepareJoinGame(p JoinGameParams, sponsored bool) (*PlayerTx, error) {
+	sender, err := PlayerAddress(p.PlayerPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	g := f.bingoGame(p.GameID)
+	if g == nil {
+		_, err := f.bingoAbort("ERROR_GAME_NOT_INITIALIZED", 3)
+		return nil, err
+	}
+	if g.IsStarted {
+		_, err := f.bingoAbort("ERROR_GAME_HAS_STARTED", 4)
+		return nil, err
+	}
+	t := f.playerTx(Call{Module: f.BingoModule(), Function: "join_game", GameID: p.GameID, Params: p}, sender)
+	if sponsored {
+		t.FeePayer = f.Admin
+	}
+	return t, nil
+}
+
+// SubmitSigned applies the call of t from t.Sender. The signature is not
+// checked.
+func (f *FakeChain) SubmitSigned(t *PlayerTx, publicKey, signature string) (*TxResult, error) {
+	sender, err := PlayerAddress(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	if !aptos.SameAddress(sender, t.Sender) {
+		return nil, ErrInvalidPublicKey
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	tx, err := f.applySigned(t)
+	if tx != nil {
+		tx.Result.Sender = t.Sender
+	}
+	return tx, err
+}
+
+func (f *FakeChain) applySigned(t *PlayerTx) (*TxResult, error) {
+	switch p := t.Call.Params.(type) {
+	case JoinGameParams:
+		return f.joinGame(t.Sender, p)
+	case ClaimPrizeParams:
+		return f.claimPrize(t.Sender, p)
+	case SnlJoinGameParams:
+		return f.snlJoinGame(t.Sender, p)
+	case SnlRollDiceParams:
+		return f.snlRollDice(t.Sender, p)
+	default:
+		return nil, fmt.Errorf("unexpected player call %s", t.Call.Function)
+	}
+}
+
+func (f *FakeChain) joinGame(sender string, p JoinGameParams) (*TxResult, error) {
+	g := f.bingoGame(p.GameID)
+	if g == nil {
+		return f.bingoAbort("ERROR_GAME_NOT_INITIALIZED", 3)
+	}
+	if g.IsStarted {
+		return f.bingoAbort("ERROR_GAME_HAS_STARTED", 4)
+	}
+	if len(p.Ticket) != 3 {
+		return nil, fmt.Errorf("ticket must have 3 rows, got %d", len(p.Ticket))
+	}
+	for _, card := range g.Cards {
+		if sameCard(card, p.Ticket) {
+			return f.bingoAbort("ERROR_DUPLICATED_TICKET", 11)
+		}
+	}
+	if contains(g.Buyers, sender) {
+		return f.bingoAbort("ERROR_USER_ALREADY_BOUGHT_ONE", 12)
+	}
+	if f.Balances[sender] < g.MintPrice {
+		return f.bingoAbort("ERROR_INSUFFICIENT_BALANCE", 2)
+	}
+	f.transfer(sender, bingoTreasury(p.GameID), g.MintPrice)
+
+	card := make([][]int, 3)
+	for i, row := range p.Ticket {
+		card[i] = append([]int(nil), row...)
+	}
+	cardAddress := f.newAddress()
+	f.owners[cardAddress] = sender
+	g.Cards = append(g.Cards, card)
+	g.Buyers = append(g.Buyers, sender)
+	g.CardAddresses = append(g.CardAddresses, cardAddress)
+	return f.success(
+		f.objectTransfer(cardAddress, bingoTreasury(p.GameID), sender),
+		f.event(f.BingoModule(), "JoinGameEvent", map[string]interface{}{
+			"game_id":   p.GameID,
+			"player":    sender,
+			"timestamp": f.now(),
+		}))
+}
+
+func sameCard(a, b [][]int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if len(a[i]) != len(b[i]) {
+			return false
+		}
+		for j := range a[i] {
+			if a[i][j] != b[i][j] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (f *FakeChain) StartGame(p StartGameParams) (*TxResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	g := f.bingoGame(p.GameID)
+	if g == nil {
+		return f.bingoAbort("ERROR_GAME_NOT_INITIALIZED", 3)
+	}
+	if g.IsStarted {
+		return f.bingoAbort("ERROR_GAME_HAS_STARTED", 4)
+	}
+	if f.now() < g.StartLastDrawnAt {
+		return f.bingoAbort("ERROR_CANT_START_GAME_YET", 6)
+	}
+	g.IsStarted = true
+	g.StartLastDrawnAt = f.now()
+	return f.success(f.gameEvent(f.BingoModule(), "GameStartedEvent", p.GameID))
+}
+
+func (f *FakeChain) DrawNumber(p DrawNumberParams) (*TxResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	g := f.bingoGame(p.GameID)
+	if g == nil {
+		return f.bingoAbort("ERROR_GAME_NOT_INITIALIZED", 3)
+	}
+	if !g.IsStarted {
+		return f.bingoAbort("ERROR_GAME_NOT_STARTED", 5)
+	}
+	if g.IsFinished {
+		return f.bingoAbort("ERROR_GAME_HAS_ENDED", 8)
+	}
+	if f.now() < g.StartLastDrawnAt+g.Interval {
+		return f.bingoAbort("ERROR_NEED_TO_WAIT_INTERVAL_TIME", 10)
+	}
+	fullHouseClaimed := len(g.ClaimPending.FullHouse) != 0
+	f.distributePrize(p.GameID, g)
+
+	treasury := bingoTreasury(p.GameID)
+	if fullHouseClaimed {
+		g.IsFinished = true
+		f.transfer(treasury, f.Treasury, f.Balances[treasury])
+		return f.success(f.gameEvent(f.BingoModule(), "GameEndedEvent", p.GameID))
+	}
+	i := f.rand.Intn(len(g.UndrawnNumbers))
+	number := g.UndrawnNumbers[i]
+	g.DrawnNumbers = append(g.DrawnNumbers, number)
+	g.UndrawnNumbers = append(g.UndrawnNumbers[:i], g.UndrawnNumbers[i+1:]...)
+	g.StartLastDrawnAt = f.now()
+	events := []Event{f.event(f.BingoModule(), "DrawNumberEvent", map[string]interface{}{
+		"game_id":   p.GameID,
+		"number":    number,
+		"timestamp": f.now(),
+	})}
+	if len(g.UndrawnNumbers) == 0 {
+		g.IsFinished = true
+		f.transfer(treasury, f.Treasury, f.Balances[treasury])
+		events = append(events, f.gameEvent(f.BingoModule(), "GameEndedEvent", p.GameID))
+	}
+	return f.success(events...)
+}
+
+// distributePrize pays every pending claim its share of the pool. Unlike the
+// contract, which reads the full house winner from row1, the winner is taken
+// from the full house claims.
+func (f *FakeChain) distributePrize(gameID int, g *FakeBingoGame) {
+	if g.ClaimPending.Pendings == 0 {
+		return
+	}
+	treasury := bingoTreasury(gameID)
+	pool := f.Balances[treasury]
+	claims := []*[]string{&g.ClaimPending.Row0, &g.ClaimPending.Row1, &g.ClaimPending.Row2, &g.ClaimPending.FullHouse}
+	for i, claimers := range claims {
+		split := uint64(len(*claimers))
+		for _, claimer := range *claimers {
+			f.transfer(treasury, claimer, pool*prizePool[i]/split/100)
+			g.ClaimPending.Pendings--
+		}
+		*claimers = nil
+	}
+	g.ClaimPending.WinningCards = nil
+}
+
+// PrepareClaimPrize checks the claim against the game state, like
+// PrepareJoinGame.
+func (f *FakeChain) PrepareClaimPrize(p ClaimPrizeParams) (*PlayerTx, error) {
+	sender, err := PlayerAddress(p.PlayerPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, _, err := f.checkClaim(p); err != nil {
+		return nil, err
+	}
+	return f.playerTx(Call{Module: f.BingoModule(), Function: "claim_prize", GameID: p.GameID, Params: p}, sender), nil
+}
+
+// checkClaim mirrors the asserts of claim_prize and returns the game the
+// claim is for.
+func (f *FakeChain) checkClaim(p ClaimPrizeParams) (*FakeBingoGame, *TxResult, error) {
+	g := f.bingoGame(p.GameID)
+	if g == nil {
+		tx, err := f.bingoAbort("ERROR_GAME_NOT_INITIALIZED", 3)
+		return nil, tx, err
+	}
+	rows := map[string][]int{PrizeTopLine: {0}, PrizeMiddleLine: {1}, PrizeBottomLine: {2}, PrizeFullHouse: {0, 1, 2}}
+	checkRows, ok := rows[p.Prize]
+	if !ok {
+		tx, err := f.bingoAbort("ERROR_INVALID_PRIZE_NAME", 7)
+		return nil, tx, err
+	}
+	if !g.IsStarted {
+		tx, err := f.bingoAbort("ERROR_GAME_NOT_STARTED", 5)
+		return nil, tx, err
+	}
+	cardIndex := -1
+	for i, a := range g.CardAddresses {
+		if a == p.Address {
+			cardIndex = i
+		}
+	}
+	if cardIndex < 0 {
+		return nil, nil, fmt.Errorf("card %s does not exist", p.Address)
+	}
+	for _, r := range checkRows {
+		if !rowDrawn(g.UndrawnNumbers, g.Cards[cardIndex][r]) {
+			tx, err := f.bingoAbort("ERROR_NOT_WINNING_TICKET", 9)
+			return nil, tx, err
+		}
+	}
+	return g, nil, nil
+}
+
+func (f *FakeChain) claimPrize(sender string, p ClaimPrizeParams) (*TxResult, error) {
+	g, tx, err := f.checkClaim(p)
+	if err != nil {
+		return tx, err
+	}
+	switch p.Prize {
+	case PrizeTopLine:
+		g.ClaimPending.Row0 = append(g.ClaimPending.Row0, sender)
+	case PrizeMiddleLine:
+		g.ClaimPending.Row1 = append(g.ClaimPending.Row1, sender)
+	case PrizeBottomLine:
+		g.ClaimPending.Row2 = append(g.ClaimPending.Row2, sender)
+	default:
+		g.ClaimPending.FullHouse = append(g.ClaimPending.FullHouse, sender)
+		g.ClaimPending.WinningCards = append(g.ClaimPending.WinningCards, p.Address)
+	}
+	g.ClaimPending.Pendings++
+	return f.success()
+}
+
+// CardOwner returns the owner of a card of the game.
+func (f *FakeChain) CardOwner(p CardParams) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	g := f.bingoGame(p.GameID)
+	if g == nil {
+		_, err := f.bingoAbort("ERROR_GAME_NOT_INITIALIZED", 3)
+		return "", err
+	}
+	if !contains(g.CardAddresses, p.Address) {
+		return "", ErrCardNotInGame
+	}
+	return f.owners[p.Address], nil
+}
+
+// rowDrawn mirrors check_prize: past up to 4 zeros, the first 5 numbers of
+// row must not be undrawn. check_prize aborts on a row with fewer numbers,
+// which never wins here.
+func rowDrawn(undrawn []int, row []int) bool {
+	var numbers []int
+	for i, n := range row {
+		if n == 0 && i-len(numbers) < 4 {
+			continue
+		}
+		numbers = append(numbers, n)
+	}
+	if len(numbers) < 5 {
+		return false
+	}
+	for _, n := range numbers[:5] {
+		if slices.Contains(undrawn, n) {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *FakeChain) CancelGame(p CancelGameParams) (*TxResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	g := f.bingoGame(p.GameID)
+	if g == nil {
+		return f.bingoAbort("ERROR_GAME_NOT_INITIALIZED", 3)
+	}
+	// cancel_game is sent from the admin, which created the game.
+	if f.Admin != g.Creator {
+		return f.bingoAbort("ERROR_NOT_GAME_CREATOR", 13)
+	}
+	if g.IsStarted {
+		return f.bingoAbort("ERROR_GAME_HAS_STARTED", 4)
+	}
+	treasury := bingoTreasury(p.GameID)
+	if pool := f.Balances[treasury]; pool != 0 {
+		split := uint64(len(g.CardAddresses))
+		for _, card := range g.CardAddresses {
+			f.transfer(treasury, f.owners[card], pool/split)
+		}
+		g.CardAddresses = nil
+	}
+	g.IsFinished = true
+	return f.success(f.gameEvent(f.BingoModule(), "CancelGameEvent", p.GameID))
+}
+
+// SNL module
+
+func (f *FakeChain) snlAbort(reason string, code int) (*TxResult, error) {
+	return f.abort(f.SnlModule(), reason, code)
+}
+
+func (f *FakeChain) snlGame(gameID int) *FakeSnlGame {
+	if gameID < 0 || gameID >= len(f.snlGames) {
+		return nil
+	}
+	return f.snlGames[gameID]
+}
+
+func snlTreasury(gameID int) string {
+	return "SL#" + strconv.Itoa(gameID)
+}
+
+func (f *FakeChain) SnlCreateGame(p CreateGameParams) (*TxResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	start, _ := strconv.ParseInt(p.StartTimestamp, 10, 64)
+	if start < f.now() {
+		return f.snlAbort("ERROR_INVALID_START_TIMESTAMP", 1)
+	}
+	f.snlGames = append(f.snlGames, &FakeSnlGame{
+		Creator:           f.Admin,
+		Name:              p.GameName,
+		CollectionAddress: f.newAddress(),
+		MintPrice:         p.MintPrice,
+		Interval:          int64(p.Interval),
+		StartTimestamp:    start,
+		lastRoll:          make(map[string]int64),
+	})
+	return f.success(f.event(f.SnlModule(), "CreateGameEvent", map[string]interface{}{
+		"creator":         f.Admin,
+		"game_name":       p.GameName,
+		"game_id":         len(f.snlGames) - 1,
+		"start_timestamp": start,
+		"timestamp":       f.now(),
+	}))
+}
+
+// PrepareSnlJoinGame checks the call against the game state, like
+// PrepareJoinGame.
+func (f *FakeChain) PrepareSnlJoinGame(p SnlJoinGameParams) (*PlayerTx, error) {
+	sender, err := PlayerAddress(p.PlayerPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	g := f.snlGame(p.GameID)
+	if g == nil {
+		_, err := f.snlAbort("ERROR_GAME_NOT_INITIALIZED", 3)
+		return nil, err
+	}
+	if g.IsStarted {
+		_, err := f.snlAbort("ERROR_GAME_HAS_STARTED", 4)
+		return nil, err
+	}
+	return f.playerTx(Call{Module: f.SnlModule(), Function: "join_game", GameID: p.GameID, Params: p}, sender), nil
+}
+
+func (f *FakeChain) snlJoinGame(sender string, p SnlJoinGameParams) (*TxResult, error) {
+	g := f.snlGame(p.GameID)
+	if g == nil {
+		return f.snlAbort("ERROR_GAME_NOT_INITIALIZED", 3)
+	}
+	if g.IsStarted {
+		return f.snlAbort("ERROR_GAME_HAS_STARTED", 4)
+	}
+	if contains(g.Buyers, sender) {
+		return f.snlAbort("ERROR_USER_ALREADY_BOUGHT_ONE", 9)
+	}
+	if f.Balances[sender] < g.MintPrice {
+		return f.snlAbort("ERROR_INSUFFICIENT_BALANCE", 2)
+	}
+	f.transfer(sender, snlTreasury(p.GameID), g.MintPrice)
+	avatar := f.newAddress()
+	f.owners[avatar] = sender
+	g.Buyers = append(g.Buyers, sender)
+	g.Avatars = append(g.Avatars, avatar)
+	return f.success(
+		f.objectTransfer(avatar, snlTreasury(p.GameID), sender),
+		f.event(f.SnlModule(), "JoinGameEvent", map[string]interface{}{
+			"game_id":   p.GameID,
+			"user":      sender,
+			"timestamp": f.now(),
+		}))
+}
+
+func (f *FakeChain) SnlStartGame(p StartGameParams) (*TxResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	g := f.snlGame(p.GameID)
+	if g == nil {
+		return f.snlAbort("ERROR_GAME_NOT_INITIALIZED", 3)
+	}
+	if g.IsStarted {
+		return f.snlAbort("ERROR_GAME_HAS_STARTED", 4)
+	}
+	if f.now() < g.StartTimestamp {
+		return f.snlAbort("ERROR_CANT_START_GAME_YET", 6)
+	}
+	g.IsStarted = true
+	g.StartTimestamp = f.now()
+	return f.success(f.gameEvent(f.SnlModule(), "GameStartedEvent", p.GameID))
+}
+
+// PrepareSnlRollDice checks the roll against the game state, like
+// PrepareJoinGame.
+func (f *FakeChain) PrepareSnlRollDice(p SnlRollDiceParams) (*PlayerTx, error) {
+	sender, err := PlayerAddress(p.PlayerPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, _, err := f.checkRoll(sender, p); err != nil {
+		return nil, err
+	}
+	return f.playerTx(Call{Module: f.SnlModule(), Function: "roll_dice", GameID: p.GameID, Params: p}, sender), nil
+}
+
+// checkRoll mirrors the asserts of roll_dice and returns the game the roll is
+// for.
+func (f *FakeChain) checkRoll(sender string, p SnlRollDiceParams) (*FakeSnlGame, *TxResult, error) {
+	g := f.snlGame(p.GameID)
+	if g == nil {
+		tx, err := f.snlAbort("ERROR_GAME_NOT_INITIALIZED", 3)
+		return nil, tx, err
+	}
+	if !g.IsStarted {
+		tx, err := f.snlAbort("ERROR_GAME_NOT_STARTED", 5)
+		return nil, tx, err
+	}
+	if g.IsFinished {
+		tx, err := f.snlAbort("ERROR_GAME_HAS_ENDED", 7)
+		return nil, tx, err
+	}
+	if !aptos.SameAddress(f.owners[p.AvatarAddress], sender) {
+		tx, err := f.snlAbort("ERROR_NOT_AVATAR_OWNER", 11)
+		return nil, tx, err
+	}
+	if f.now() < g.lastRoll[p.AvatarAddress]+g.Interval {
+		tx, err := f.snlAbort("ERROR_NEED_TO_WAIT_INTERVAL_TIME", 8)
+		return nil, tx, err
+	}
+	return g, nil, nil
+}
+
+func (f *FakeChain) snlRollDice(sender string, p SnlRollDiceParams) (*TxResult, error) {
+	g, tx, err := f.checkRoll(sender, p)
+	if err != nil {
+		return tx, err
+	}
+	g.lastRoll[p.AvatarAddress] = f.now()
+	// two dice, like get_new_number
+	rolled := f.rand.Intn(6) + 1 + f.rand.Intn(6) + 1
+	return f.success(f.event(f.SnlModule(), "RollDiceEvent", map[string]interface{}{
+		"game_id":   p.GameID,
+		"user":      sender,
+		"number":    rolled,
+		"timestamp": f.now(),
+	}))
+}
+
+// SnlGameWon records the winner. game_won is admin only and is sent from
+// the admin.
+func (f *FakeChain) SnlGameWon(p SnlGameWonParams) (*TxResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	g := f.snlGame(p.GameID)
+	if g == nil {
+		return f.snlAbort("ERROR_GAME_NOT_INITIALIZED", 3)
+	}
+	if !g.IsStarted {
+		return f.snlAbort("ERROR_GAME_NOT_STARTED", 5)
+	}
+	if g.IsFinished {
+		return f.snlAbort("ERROR_GAME_HAS_ENDED", 7)
+	}
+	g.Winner = p.User
+	return f.success(f.event(f.SnlModule(), "GameWonEvent", map[string]interface{}{
+		"game_id":   p.GameID,
+		"user":      p.User,
+		"timestamp": f.now(),
+	}))
+}
+
+// AvatarOwner returns the owner of an avatar of the game.
+func (f *FakeChain) AvatarOwner(p CardParams) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	g := f.snlGame(p.GameID)
+	if g == nil {
+		_, err := f.snlAbort("ERROR_GAME_NOT_INITIALIZED", 3)
+		return "", err
+	}
+	if !contains(g.Avatars, p.Address) {
+		return "", ErrAvatarNotInGame
+	}
+	return f.owners[p.Address], nil
+}
+
+func (f *FakeChain) SnlCancelGame(p CancelGameParams) (*TxResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	g := f.snlGame(p.GameID)
+	if g == nil {
+		return f.snlAbort("ERROR_GAME_NOT_INITIALIZED", 3)
+	}
+	// cancel_game is sent from the admin, which created the game.
+	if f.Admin != g.Creator {
+		return f.snlAbort("ERROR_NOT_GAME_CREATOR", 10)
+	}
+	if g.IsStarted {
+		return f.snlAbort("ERROR_GAME_HAS_STARTED", 4)
+	}
+	treasury := snlTreasury(p.GameID)
+	if pool := f.Balances[treasury]; pool != 0 {
+		split := uint64(len(g.Avatars))
+		for _, avatar := range g.Avatars {
+			f.transfer(treasury, f.owners[avatar], pool/split)
+		}
+		g.Avatars = nil
+	}
+	g.IsFinished = true
+	return f.success(f.gameEvent(f.SnlModule(), "CancelGameEvent", p.GameID))
+}
+
+// QueueDepth is always 0, FakeChain applies calls as they come.
+func (f *FakeChain) QueueDepth() int {
+	return 0
+}
+
+// OperatorCount is 1, FakeChain sends everything from Admin.
+func (f *FakeChain) OperatorCount() int {
+	return 1
+}
+
+// Operators reports the admin as the only account.
+func (f *FakeChain) Operators() ([]Operator, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	balance := f.Balances[f.Admin]
+	return []Operator{{
+		Address: f.Admin,
+		Admin:   true,
+		Balance: balance,
+		Low:     balance < MinOperatorBalanceFromEnv(),
+	}}, nil
+}
+
+func (f *FakeChain) BingoGameState(p GameStateParams) (*GameState, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if p.GameID < 0 || p.GameID >= len(f.games) {
+		return nil, fmt.Errorf("game %d: %w", p.GameID, ErrGameNotInitialized)
+	}
+	g := f.games[p.GameID]
+	return &GameState{
+		CardsSold:         len(g.Cards),
+		CollectionAddress: g.CollectionAddress,
+		PrizePool:         f.Balances[bingoTreasury(p.GameID)],
+		IsStarted:         g.IsStarted,
+		IsFinished:        g.IsFinished,
+	}, nil
+}
+
+func (f *FakeChain) SnlGameState(p GameStateParams) (*GameState, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if p.GameID < 0 || p.GameID >= len(f.snlGames) {
+		return nil, fmt.Errorf("game %d: %w", p.GameID, ErrGameNotInitialized)
+	}
+	g := f.snlGames[p.GameID]
+	return &GameState{
+		CardsSold:         len(g.Buyers),
+		CollectionAddress: g.CollectionAddress,
+		PrizePool:         f.Balances[snlTreasury(p.GameID)],
+		IsStarted:         g.IsStarted,
+		IsFinished:        g.IsFinished,
+	}, nil
+}
@@ -0,0 +1,89 @@
+package smartcontract
+
+import (
+	"VirtueGaming/utils/aptos"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	defaultBingoModuleName = "bingov2"
+	defaultSnlModuleName   = "SNL"
+)
+
+// Module identifies a deployed Move module, e.g. 0x1234::bingov2. Games keep
+// the module that created them so they can still be served after a newer
+// version is deployed under another name.
+type Module struct {
+	Address string
+	Name    string
+}
+
+// ParseModule parses the "<address>::<name>" form returned by Module.ID. The
+// address is normalized to its long form.
+func ParseModule(id string) (Module, error) {
+	parts := strings.Split(id, "::")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return Module{}, fmt.Errorf("invalid module id %q", id)
+	}
+	addr, err := aptos.ParseAddress(parts[0])
+	if err != nil {
+		return Module{}, fmt.Errorf("invalid module id %q: %w", id, err)
+	}
+	return Module{Address: addr.String(), Name: parts[1]}, nil
+}
+
+func (m Module) IsZero() bool {
+	return m.Address == "" && m.Name == ""
+}
+
+func (m Module) ID() string {
+	return m.Address + "::" + m.Name
+}
+
+// Function returns the fully qualified id of an entry or view function.
+func (m Module) Function(name string) string {
+	return m.ID() + "::" + name
+}
+
+// EventType returns the fully qualified type of an event struct.
+func (m Module) EventType(name string) string {
+	return m.ID() + "::" + name
+}
+
+// or returns m, or def when m is not set.
+func (m Module) or(def Module) Module {
+	if m.IsZero() {
+		return def
+	}
+	return m
+}
+
+func moduleFromEnv(addressKey, nameKey, defaultName string) Module {
+	m := Module{Address: os.Getenv(addressKey), Name: os.Getenv(nameKey)}
+	if m.Address == "" {
+		m.Address = os.Getenv("APTOS_FUNCTION_ID")
+	}
+	if m.Name == "" {
+		m.Name = defaultName
+	}
+	// Event types carry the long form of the address, which ID has to match
+	// exactly.
+	if addr, err := aptos.ParseAddress(m.Address); err == nil {
+		m.Address = addr.String()
+	}
+	return m
+}
+
+// BingoModuleFromEnv reads BINGO_MODULE_ADDRESS and BINGO_MODULE_NAME. The
+// address defaults to APTOS_FUNCTION_ID and the name to bingov2.
+func BingoModuleFromEnv() Module {
+	return moduleFromEnv("BINGO_MODULE_ADDRESS", "BINGO_MODULE_NAME", defaultBingoModuleName)
+}
+
+// SnlModuleFromEnv reads SNL_MODULE_ADDRESS and SNL_MODULE_NAME. The address
+// defaults to APTOS_FUNCTION_ID and the name to SNL.
+func SnlModuleFromEnv() Module {
+	return moduleFromEnv("SNL_MODULE_ADDRESS", "SNL_MODULE_NAME", defaultSnlModuleName)
+}
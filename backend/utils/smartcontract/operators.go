@@ -0,0 +1,146 @@
+package smartcontract
+
+import (
+	"VirtueGaming/utils/aptos"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultMinOperatorBalance = aptos.OctasPerAPT
+	balanceCheckInterval      = 5 * time.Minute
+)
+
+// Operator is an account of the pool and how it is doing.
+type Operator struct {
+	Address    string `json:"address"`
+	Admin      bool   `json:"admin"`
+	Balance    uint64 `json:"balance"`
+	QueueDepth int    `json:"queueDepth"`
+	// Low is set when Balance is below the configured minimum, or when the
+	// balance could not be read, in which case Error says why.
+	Low   bool   `json:"low"`
+	Error string `json:"error,omitempty"`
+}
+
+// SetOperators replaces the operator pool with the accounts of keys. The calls
+// anyone may send, start_game and draw_number, are spread over the pool while
+// everything else keeps going out from the admin account. Blank keys are
+// skipped.
+func (c *AptosClient) SetOperators(keys []string) error {
+	operators := make([]*aptos.SignerQueue, 0, len(keys))
+	for i, key := range keys {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		signer, err := aptos.NewAccountFromHex(key)
+		if err != nil {
+			return fmt.Errorf("operator key %d: %w", i, err)
+		}
+		operators = append(operators, aptos.NewSignerQueue(c.client, signer))
+	}
+	if len(operators) == 0 {
+		operators = append(operators, c.queue)
+	}
+	c.operators = operators
+	return nil
+}
+
+// submitAny sends a permissionless call from the operator with the shortest
+// queue, taking turns between operators that are equally busy.
+func (c *AptosClient) submitAny(call Call, args ...[]byte) (*TxResult, error) {
+	start := int(atomic.AddUint32(&c.next, 1)) % len(c.operators)
+	best := c.operators[start]
+	for i := 1; i < len(c.operators); i++ {
+		q := c.operators[(start+i)%len(c.operators)]
+		if q.Depth() < best.Depth() {
+			best = q
+		}
+	}
+	return c.submitFrom(best, call, args...)
+}
+
+func (c *AptosClient) OperatorCount() int {
+	return len(c.operators)
+}
+
+// Operators reports the admin account and the operator pool with their
+// balances. An account whose balance cannot be read is reported as low
+// rather than failing the whole report.
+func (c *AptosClient) Operators() ([]Operator, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), viewTimeout)
+	defer cancel()
+	min := MinOperatorBalanceFromEnv()
+	queues := []*aptos.SignerQueue{c.queue}
+	for _, q := range c.operators {
+		if q != c.queue {
+			queues = append(queues, q)
+		}
+	}
+	operators := make([]Operator, 0, len(queues))
+	for _, q := range queues {
+		address := q.Signer().Address.String()
+		o := Operator{
+			Address:    address,
+			Admin:      q == c.queue,
+			QueueDepth: q.Depth(),
+		}
+		balance, err := c.balance(ctx, address)
+		if err != nil {
+			o.Low = true
+			o.Error = err.Error()
+		} else {
+			o.Balance = balance
+			o.Low = balance < min
+		}
+		operators = append(operators, o)
+	}
+	return operators, nil
+}
+
+// MonitorBalances logs an alert for every account running low on APT, every
+// few minutes until ctx is done.
+func (c *AptosClient) MonitorBalances(ctx context.Context) {
+	ticker := time.NewTicker(balanceCheckInterval)
+	defer ticker.Stop()
+	for {
+		operators, err := c.Operators()
+		if err != nil {
+			logrus.Error("failed to check operator balances: ", err)
+		}
+		for _, o := range operators {
+			if o.Error != "" {
+				logrus.Errorf("failed to check the balance of operator %s: %s", o.Address, o.Error)
+			} else if o.Low {
+				logrus.Warnf("operator %s is low on funds: %.8f APT left", o.Address, float64(o.Balance)/aptos.OctasPerAPT)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// MinOperatorBalanceFromEnv reads OPERATOR_MIN_BALANCE, an amount such as
+// "0.5 APT", and defaults to 1 APT.
+func MinOperatorBalanceFromEnv() uint64 {
+	v := strings.TrimSpace(os.Getenv("OPERATOR_MIN_BALANCE"))
+	if v == "" {
+		return defaultMinOperatorBalance
+	}
+	min, err := aptos.ParseAmount(v)
+	if err != nil {
+		logrus.Errorf("invalid OPERATOR_MIN_BALANCE, using 1 APT: %s", err)
+		return defaultMinOperatorBalance
+	}
+	return min
+}
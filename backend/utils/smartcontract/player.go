@@ -0,0 +1,256 @@
+package smartcontract
+
+import (
+	"VirtueGaming/utils/aptos"
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidPublicKey = errors.New("invalid public key")
+	ErrInvalidSignature = errors.New("invalid signature")
+	// ErrNotSubmitted wraps the errors of SubmitSigned that happened before
+	// the node accepted the transaction, its gas was never spent.
+	ErrNotSubmitted = errors.New("transaction was not submitted")
+)
+
+// PlayerTx is a call sent from the player's account. The Prepare functions
+// build it, the player signs it in their wallet and SubmitSigned sends it.
+// The player pays the gas, unless FeePayer is set: the admin account then
+// sponsors the call and signs it as well.
+type PlayerTx struct {
+	Call Call
+	// Sender is the address of the player.
+	Sender   string
+	FeePayer string
+	// RawTransaction is the BCS encoded transaction, hex encoded, as wallets
+	// take it along with FeePayer.
+	RawTransaction string
+	// SigningMessage is the hex encoded message to sign, for wallets that
+	// sign raw messages.
+	SigningMessage string
+	MaxGasAmount   uint64
+	GasUnitPrice   uint64
+	ExpiresAt      time.Time
+}
+
+// Sponsored reports whether the admin account pays the gas of t.
+func (t *PlayerTx) Sponsored() bool {
+	return t.FeePayer != ""
+}
+
+// MaxCost is the most gas the transaction costs, in octas.
+func (t *PlayerTx) MaxCost() uint64 {
+	return t.MaxGasAmount * t.GasUnitPrice
+}
+
+// NewJoinTx rebuilds a PlayerTx PrepareJoinGame returned from the parts of it
+// that were stored until the player signed.
+func NewJoinTx(p JoinGameParams, sender, feePayer, rawTransaction string) *PlayerTx {
+	return &PlayerTx{
+		Call:           Call{Module: p.Module, Function: "join_game", GameID: p.GameID, Params: p},
+		Sender:         sender,
+		FeePayer:       feePayer,
+		RawTransaction: rawTransaction,
+	}
+}
+
+// NewClaimTx rebuilds a PlayerTx PrepareClaimPrize returned, see NewJoinTx.
+func NewClaimTx(p ClaimPrizeParams, sender, rawTransaction string) *PlayerTx {
+	return &PlayerTx{
+		Call:           Call{Module: p.Module, Function: "claim_prize", GameID: p.GameID, Params: p},
+		Sender:         sender,
+		RawTransaction: rawTransaction,
+	}
+}
+
+// PrepareJoinGame simulates join_game sent by the player of
+// p.PlayerPublicKey, with the admin account as fee payer when sponsored. A
+// call that would abort fails with its abort reason before the player is
+// asked to sign.
+func (c *AptosClient) PrepareJoinGame(p JoinGameParams, sponsored bool) (*PlayerTx, error) {
+	if len(p.Ticket) != 3 {
+		return nil, fmt.Errorf("ticket must have 3 rows, got %d", len(p.Ticket))
+	}
+	return c.prepare(Call{Module: p.Module.or(c.bingo), Function: "join_game", GameID: p.GameID, Params: p}, p.PlayerPublicKey, sponsored,
+		argI(p.GameID), argS(p.Uri), argRow(p.Ticket[0]), argRow(p.Ticket[1]), argRow(p.Ticket[2]))
+}
+
+// PrepareClaimPrize simulates claim_prize sent by the player of
+// p.PlayerPublicKey, who pays its gas. The contract pays the prize to the
+// sender without checking who owns the card, see CardOwner.
+func (c *AptosClient) PrepareClaimPrize(p ClaimPrizeParams) (*PlayerTx, error) {
+	address, err := argA(p.Address)
+	if err != nil {
+		return nil, err
+	}
+	return c.prepare(Call{Module: p.Module.or(c.bingo), Function: "claim_prize", GameID: p.GameID, Params: p}, p.PlayerPublicKey, false,
+		argI(p.GameID), argS(p.Prize), address)
+}
+
+// prepare builds call as sent by the player of playerPublicKey and simulates
+// it.
+func (c *AptosClient) prepare(call Call, playerPublicKey string, sponsored bool, args ...[]byte) (*PlayerTx, error) {
+	pub, err := publicKey(playerPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := aptos.ParseEntryFunction(call.Module.Function(call.Function), args...)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), txTimeout)
+	defer cancel()
+
+	t := &PlayerTx{Call: call}
+	var (
+		raw     *aptos.RawTransaction
+		sim     *aptos.Transaction
+		encoded []byte
+		message []byte
+	)
+	if sponsored {
+		feePayer := c.queue.Signer()
+		var tx *aptos.FeePayerTransaction
+		tx, raw, sim, err = c.client.BuildFeePayerTransaction(ctx, pub, feePayer, payload)
+		if err == nil {
+			t.FeePayer = feePayer.Address.String()
+			encoded, message = tx.Raw, tx.SigningMessage()
+		}
+	} else {
+		var tx *aptos.SenderTransaction
+		tx, raw, sim, err = c.client.BuildSenderTransaction(ctx, pub, payload)
+		if err == nil {
+			encoded, message = tx.Raw, tx.SigningMessage()
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("simulate %s: %w", payload.ID(), err)
+	}
+	if !sim.Success {
+		simulated := NewTxResult(sim)
+		return nil, fmt.Errorf("simulate %s: %w", payload.ID(), txError(&simulated))
+	}
+	t.Sender = raw.Sender.String()
+	t.RawTransaction = "0x" + hex.EncodeToString(encoded)
+	t.SigningMessage = "0x" + hex.EncodeToString(message)
+	t.MaxGasAmount = raw.MaxGasAmount
+	t.GasUnitPrice = raw.GasUnitPrice
+	t.ExpiresAt = time.Unix(int64(raw.ExpirationTimestampSecs), 0)
+	return t, nil
+}
+
+// SubmitSigned sends t with the signature of the player, both hex encoded,
+// adding the one of the fee payer to a sponsored call, and waits for it. The
+// public key must be the one of the sender's account, the fee payer does not
+// co-sign for anyone else.
+func (c *AptosClient) SubmitSigned(t *PlayerTx, playerPublicKey, signature string) (*TxResult, error) {
+	raw, err := decodeHex(t.RawTransaction)
+	if err != nil {
+		return nil, fmt.Errorf("invalid raw transaction: %w", err)
+	}
+	pub, err := publicKey(playerPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	if !aptos.SameAddress(aptos.AuthKey(pub).String(), t.Sender) {
+		return nil, ErrInvalidPublicKey
+	}
+	sig, err := decodeHex(signature)
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+	var signed []byte
+	if t.Sponsored() {
+		feePayer := c.queue.Signer()
+		if !aptos.SameAddress(t.FeePayer, feePayer.Address.String()) {
+			return nil, fmt.Errorf("transaction is sponsored by %s, not by %s", t.FeePayer, feePayer.Address)
+		}
+		tx := &aptos.FeePayerTransaction{Raw: raw, FeePayer: feePayer.Address}
+		if !tx.VerifySender(pub, sig) {
+			return nil, ErrInvalidSignature
+		}
+		signed = tx.Sign(pub, sig, feePayer)
+	} else {
+		tx := &aptos.SenderTransaction{Raw: raw}
+		if !tx.VerifySender(pub, sig) {
+			return nil, ErrInvalidSignature
+		}
+		signed = tx.Sign(pub, sig)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), txTimeout)
+	defer cancel()
+
+	pending, err := c.client.SubmitTransaction(ctx, signed)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w: %w", t.Call.Module.Function(t.Call.Function), ErrNotSubmitted, err)
+	}
+	return c.await(ctx, t.Call, pending)
+}
+
+// CancelMessage is the message the creator of a game signs to have the admin
+// account cancel it, create_game making the admin its creator on chain.
+func CancelMessage(m Module, gameID int) string {
+	return fmt.Sprintf("Cancel game %d of %s", gameID, m.ID())
+}
+
+// GameWonMessage is the message the creator of a snakes and ladders game, or
+// an operator, signs to have the admin account declare the winner with
+// game_won, which only the admin may send.
+func GameWonMessage(p SnlGameWonParams) string {
+	return fmt.Sprintf("Declare %s winner of game %d of %s with avatar %s, %d snakes and %d ladders",
+		p.User, p.GameID, p.Module.ID(), p.AvatarAddress, p.Snakes, p.Ladders)
+}
+
+// VerifyMessage checks signature, hex encoded, of message by the player of
+// playerPublicKey. Wallets sign messages wrapped in an envelope such as
+// "APTOS\nmessage: <message>\nnonce: <nonce>", fullMessage is that envelope
+// when the wallet used one.
+func VerifyMessage(playerPublicKey, message, fullMessage, signature string) error {
+	pub, err := publicKey(playerPublicKey)
+	if err != nil {
+		return err
+	}
+	sig, err := decodeHex(signature)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	signed := message
+	if fullMessage != "" {
+		if !strings.Contains(fullMessage+"\n", "\nmessage: "+message+"\n") {
+			return ErrInvalidSignature
+		}
+		signed = fullMessage
+	}
+	if !ed25519.Verify(pub, []byte(signed), sig) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// PlayerAddress is the address of the account of a hex encoded ed25519
+// public key, the sender of the player transactions signed with it.
+func PlayerAddress(playerPublicKey string) (string, error) {
+	pub, err := publicKey(playerPublicKey)
+	if err != nil {
+		return "", err
+	}
+	return aptos.AuthKey(pub).String(), nil
+}
+
+func publicKey(s string) (ed25519.PublicKey, error) {
+	pub, err := decodeHex(s)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return nil, ErrInvalidPublicKey
+	}
+	return pub, nil
+}
+
+func decodeHex(s string) ([]byte, error) {
+	return hex.DecodeString(strings.TrimPrefix(s, "0x"))
+}
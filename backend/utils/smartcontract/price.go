@@ -19,6 +19,9 @@ func GetCoinPrice() (float64, error) {
 		return 0, fmt.Errorf("error fetching data: %w", err)
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("error fetching data: %s", resp.Status)
+	}
 
 	var result CoinGeckoResponse
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
@@ -0,0 +1,50 @@
+package smartcontract
+
+import (
+	"VirtueGaming/utils/aptos"
+	"context"
+	"errors"
+)
+
+// NoGame is the GameID of calls that are not on an existing game.
+const NoGame = -1
+
+// Call is an entry function call as the Recorder sees it.
+type Call struct {
+	Module   Module
+	Function string
+	// GameID is the game the call is on, NoGame for create_game.
+	GameID int
+	// Params are the params the call was built from.
+	Params interface{}
+}
+
+// Recorder keeps track of the transactions AptosClient submits. Submitted is
+// called once the node accepted a transaction, Committed once it succeeded or
+// failed. A transaction whose wait timed out is never committed from the
+// point of view of the client.
+type Recorder interface {
+	Submitted(call Call, tx *TxResult)
+	Committed(call Call, tx *TxResult)
+}
+
+func (c *AptosClient) SetRecorder(r Recorder) {
+	c.recorder = r
+}
+
+// Transaction looks a transaction up on the node. It returns nil while the
+// transaction is pending or not known to the node.
+func (c *AptosClient) Transaction(ctx context.Context, hash string) (*TxResult, error) {
+	tx, err := c.client.TransactionByHash(ctx, hash)
+	if errors.Is(err, aptos.ErrTransactionNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if tx.Pending() {
+		return nil, nil
+	}
+	r := NewTxResult(tx)
+	return &r, nil
+}
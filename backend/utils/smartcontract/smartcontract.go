@@ -1,24 +1,43 @@
 package smartcontract
 
 import (
-	"errors"
+	"VirtueGaming/utils/aptos"
+	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"strconv"
 	"strings"
+	"time"
 )
 
-func argS(s string) string {
-	return "string:" + s
+// txTimeout bounds a whole build, submit and wait round trip.
+const txTimeout = 2 * time.Minute
+
+func argS(s string) []byte {
+	return aptos.SerializeString(s)
+}
+
+func argA(s string) ([]byte, error) {
+	a, err := aptos.ParseAddress(s)
+	if err != nil {
+		return nil, err
+	}
+	return aptos.SerializeAddress(a), nil
+}
+func argI(i int) []byte {
+	return aptos.SerializeU64(uint64(i))
 }
 
-func argA(s string) string {
-	return "address:" + s
+func argU(u uint64) []byte {
+	return aptos.SerializeU64(u)
 }
-func argI(i int) string {
-	s := strconv.Itoa(i)
-	return "u64:" + s
+
+func argRow(row []int) []byte {
+	v := make([]uint64, len(row))
+	for i, n := range row {
+		v[i] = uint64(n)
+	}
+	return aptos.SerializeU64Vector(v)
 }
 
 type DelegateReviewParams struct {
@@ -32,123 +51,251 @@ type DelegateReviewParams struct {
 	SiteSafety    string
 }
 
+// Limits aptos_token_objects puts on a collection.
+const (
+	maxCollectionNameLength        = 128
+	maxCollectionDescriptionLength = 2048
+	maxCollectionURILength         = 512
+	royaltyDenominator             = 100
+)
+
 type CreateGameParams struct {
 	GameName       string
 	StartTimestamp string
+	// MintPrice is the price of a card in octas.
+	MintPrice uint64
+	// Interval is the minimum number of seconds between two draws.
+	Interval              uint64
+	CollectionName        string
+	CollectionDescription string
+	CollectionURI         string
+	// RoyaltyNumerator is the royalty in percent, create_game uses a
+	// denominator of 100.
+	RoyaltyNumerator uint64
 }
 
+// Validate checks the params against the rules of create_game so a bad game
+// is rejected before it costs gas.
+func (p CreateGameParams) Validate() error {
+	switch {
+	case p.GameName == "":
+		return fmt.Errorf("game name is required")
+	case p.MintPrice == 0:
+		return fmt.Errorf("mint price must be positive")
+	case p.Interval == 0:
+		return fmt.Errorf("interval must be positive")
+	case p.CollectionName == "":
+		return fmt.Errorf("collection name is required")
+	case len(p.CollectionName) > maxCollectionNameLength:
+		return fmt.Errorf("collection name is longer than %d bytes", maxCollectionNameLength)
+	case len(p.CollectionDescription) > maxCollectionDescriptionLength:
+		return fmt.Errorf("collection description is longer than %d bytes", maxCollectionDescriptionLength)
+	case len(p.CollectionURI) > maxCollectionURILength:
+		return fmt.Errorf("collection uri is longer than %d bytes", maxCollectionURILength)
+	case p.RoyaltyNumerator > royaltyDenominator:
+		return fmt.Errorf("royalty numerator must be at most %d", royaltyDenominator)
+	}
+	if _, err := strconv.ParseUint(p.StartTimestamp, 10, 64); err != nil {
+		return fmt.Errorf("invalid start timestamp: %w", err)
+	}
+	return nil
+}
+
+// Prize names accepted by claim_prize.
+const (
+	PrizeTopLine    = "row0"
+	PrizeMiddleLine = "row1"
+	PrizeBottomLine = "row2"
+	PrizeFullHouse  = "fh"
+)
+
+// The params of calls on an existing game carry the module the game was
+// created on. A zero Module targets the configured module.
+
 type ClaimPrizeParams struct {
-	GameID  int
-	Prize   string
+	Module Module
+	GameID int
+	Prize  string
+	// Address is the address of the card object the prize is claimed with.
 	Address string
+	// PlayerPublicKey is the hex encoded ed25519 key of the player the claim
+	// is sent from, the prize goes to their account.
+	PlayerPublicKey string
 }
 type DrawNumberParams struct {
+	Module Module
 	GameID int
 }
 
 type JoinGameParams struct {
+	Module Module
 	GameID int
 	Uri    string
 	Ticket [][]int
+	// PlayerPublicKey is the hex encoded ed25519 key of the player the join
+	// is sent from, see PrepareJoinGame.
+	PlayerPublicKey string
 }
 
-var ErrMetadataDuplicated = errors.New("metadata already exist")
+type StartGameParams struct {
+	Module Module
+	GameID int
+}
 
-func CallCreateGame(p CreateGameParams) (*TxResult, error) {
-	gas_unit, _ := strconv.Atoi(os.Getenv("GAS_UNITS"))
-	gas_price, _ := strconv.Atoi(os.Getenv("GAS_PRICE"))
-	fmt.Println(gas_unit)
-	fmt.Println(gas_price)
+type CancelGameParams struct {
+	Module Module
+	GameID int
+}
 
-	command := fmt.Sprintf("move run --function-id %s::bingov1::create_game --max-gas %d --gas-unit-price %d --args", os.Getenv("APTOS_FUNCTION_ID"), gas_unit, gas_price)
-	args := append(strings.Split(command, " "),
-		argS(p.GameName), "u64:"+p.StartTimestamp, "u64:100000000", "u64:1", "string:Collection_name", "string:desc", "string:uri", "u64:1")
-	cmd := exec.Command("aptos", args...)
-	fmt.Println(strings.Join(args, " "))
+// AptosClient submits calls through an Aptos node, from the admin account or,
+// for the calls anyone may send, from the operator pool.
+type AptosClient struct {
+	client *aptos.Client
+	queue  *aptos.SignerQueue
+	// operators send the calls anyone may send, see submitAny.
+	operators []*aptos.SignerQueue
+	next      uint32
+	bingo     Module
+	snl       Module
+	recorder  Recorder
+}
 
-	o, err := cmd.Output()
+func NewAptosClient(nodeURL, privateKey string, bingo, snl Module) (*AptosClient, error) {
+	signer, err := aptos.NewAccountFromHex(privateKey)
 	if err != nil {
-		if err, ok := err.(*exec.ExitError); ok {
-			if strings.Contains(string(o), "ERROR_METADATA_DUPLICATED(0x3)") {
-				return nil, fmt.Errorf("%w: %w", ErrMetadataDuplicated, err)
-			}
-			return nil, fmt.Errorf("stderr: %s out: %s err: %w", err.Stderr, o, err)
-		}
-		return nil, fmt.Errorf("out: %s err: %w", o, err)
+		return nil, err
 	}
-
-	txResult, err := UnmarshalTxResult(o)
-	return &txResult, err
+	client := aptos.NewClient(nodeURL)
+	registerAbortCodes(bingo.Name, bingoAbortCodes)
+	registerAbortCodes(snl.Name, snlAbortCodes)
+	queue := aptos.NewSignerQueue(client, signer)
+	return &AptosClient{
+		client:    client,
+		queue:     queue,
+		operators: []*aptos.SignerQueue{queue},
+		bingo:     bingo,
+		snl:       snl,
+	}, nil
 }
 
-func CallClaimPrize(p ClaimPrizeParams) (*TxResult, error) {
-	gas_unit, _ := strconv.Atoi(os.Getenv("GAS_UNITS"))
-	gas_price, _ := strconv.Atoi(os.Getenv("GAS_PRICE"))
-	command := fmt.Sprintf("move run --function-id %s::bingov1::claim_prize --max-gas %d --gas-unit-price %d --args", os.Getenv("APTOS_FUNCTION_ID"), gas_unit, gas_price)
-	args := append(strings.Split(command, " "),
-		argI(p.GameID), argS(p.Prize), argA(p.Address))
-	cmd := exec.Command("aptos", args...)
-	fmt.Println(strings.Join(args, " "))
-
-	o, err := cmd.Output()
+// NewAptosClientFromEnv reads APTOS_NODE_URL, APTOS_PRIVATE_KEY,
+// OPERATOR_PRIVATE_KEYS and the module variables described in
+// BingoModuleFromEnv and SnlModuleFromEnv. Gas is estimated per call, see
+// submitFrom.
+func NewAptosClientFromEnv() (*AptosClient, error) {
+	c, err := NewAptosClient(os.Getenv("APTOS_NODE_URL"), os.Getenv("APTOS_PRIVATE_KEY"), BingoModuleFromEnv(), SnlModuleFromEnv())
 	if err != nil {
-		if err, ok := err.(*exec.ExitError); ok {
-			if strings.Contains(string(o), "ERROR_METADATA_DUPLICATED(0x3)") {
-				return nil, fmt.Errorf("%w: %w", ErrMetadataDuplicated, err)
-			}
-			return nil, fmt.Errorf("stderr: %s out: %s err: %w", err.Stderr, o, err)
+		return nil, err
+	}
+	if keys := os.Getenv("OPERATOR_PRIVATE_KEYS"); keys != "" {
+		if err := c.SetOperators(strings.Split(keys, ",")); err != nil {
+			return nil, err
 		}
-		return nil, fmt.Errorf("out: %s err: %w", o, err)
 	}
+	return c, nil
+}
 
-	txResult, err := UnmarshalTxResult(o)
-	return &txResult, err
+func (c *AptosClient) BingoModule() Module {
+	return c.bingo
 }
 
-func CallDrawNumber(p DrawNumberParams) (*TxResult, error) {
-	gas_unit, _ := strconv.Atoi(os.Getenv("GAS_UNITS"))
-	gas_price, _ := strconv.Atoi(os.Getenv("GAS_PRICE"))
-	command := fmt.Sprintf("move run --function-id %s::bingov1::draw_number --max-gas %d --gas-unit-price %d --args", os.Getenv("APTOS_FUNCTION_ID"), gas_unit, gas_price)
-	args := append(strings.Split(command, " "),
-		argI(p.GameID))
-	cmd := exec.Command("aptos", args...)
-	fmt.Println(strings.Join(args, " "))
+func (c *AptosClient) SnlModule() Module {
+	return c.snl
+}
 
-	o, err := cmd.Output()
-	if err != nil {
-		if err, ok := err.(*exec.ExitError); ok {
-			if strings.Contains(string(o), "ERROR_METADATA_DUPLICATED(0x3)") {
-				return nil, fmt.Errorf("%w: %w", ErrMetadataDuplicated, err)
-			}
-			return nil, fmt.Errorf("stderr: %s out: %s err: %w", err.Stderr, o, err)
+// QueueDepth is the number of calls waiting to be submitted, over all
+// accounts.
+func (c *AptosClient) QueueDepth() int {
+	depth := c.queue.Depth()
+	for _, q := range c.operators {
+		if q != c.queue {
+			depth += q.Depth()
 		}
-		return nil, fmt.Errorf("out: %s err: %w", o, err)
 	}
+	return depth
+}
 
-	txResult, err := UnmarshalTxResult(o)
-	return &txResult, err
+// submit sends the call from the admin account.
+func (c *AptosClient) submit(call Call, args ...[]byte) (*TxResult, error) {
+	return c.submitFrom(c.queue, call, args...)
 }
 
-func CallJoinGame(p JoinGameParams) (*TxResult, error) {
-	gas_unit, _ := strconv.Atoi(os.Getenv("GAS_UNITS"))
-	gas_price, _ := strconv.Atoi(os.Getenv("GAS_PRICE"))
-	command := fmt.Sprintf("move run --function-id %s::bingov1::join_game --max-gas %d --gas-unit-price %d --args", os.Getenv("APTOS_FUNCTION_ID"), gas_unit, gas_price)
-	args := append(strings.Split(command, " "),
-		argI(p.GameID), argS(p.Uri), "u64:")
-	cmd := exec.Command("aptos", args...)
-	fmt.Println(strings.Join(args, " "))
+// submitFrom simulates the call from the account of queue first, so a call
+// that would abort fails with its abort reason without costing gas, and sizes
+// its gas from the simulation and the node's gas price. It then sends the
+// call through the queue and waits for the result. The recorder, when set, learns about the
+// transaction as soon as the node accepted it and again once it is committed.
+func (c *AptosClient) submitFrom(queue *aptos.SignerQueue, call Call, args ...[]byte) (*TxResult, error) {
+	payload, err := aptos.ParseEntryFunction(call.Module.Function(call.Function), args...)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), txTimeout)
+	defer cancel()
 
-	o, err := cmd.Output()
+	gas, sim, err := c.client.EstimateGas(ctx, queue.Signer(), payload)
 	if err != nil {
-		if err, ok := err.(*exec.ExitError); ok {
-			if strings.Contains(string(o), "ERROR_METADATA_DUPLICATED(0x3)") {
-				return nil, fmt.Errorf("%w: %w", ErrMetadataDuplicated, err)
-			}
-			return nil, fmt.Errorf("stderr: %s out: %s err: %w", err.Stderr, o, err)
-		}
-		return nil, fmt.Errorf("out: %s err: %w", o, err)
+		return nil, fmt.Errorf("simulate %s: %w", payload.ID(), err)
+	}
+	if !sim.Success {
+		simulated := NewTxResult(sim)
+		return nil, fmt.Errorf("simulate %s: %w", payload.ID(), txError(&simulated))
+	}
+	pending, err := queue.Submit(ctx, payload, gas)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", payload.ID(), err)
+	}
+	txResult, err := c.await(ctx, call, pending)
+	if err != nil && txResult == nil {
+		queue.Reset()
+		return nil, fmt.Errorf("%s: %w", payload.ID(), err)
+	}
+	return txResult, err
+}
+
+// await reports the pending transaction of call to the recorder and waits
+// for it to be committed. The result is nil when the wait failed, and set
+// along with the abort reason when the transaction failed.
+func (c *AptosClient) await(ctx context.Context, call Call, pending *aptos.Transaction) (*TxResult, error) {
+	if c.recorder != nil {
+		submitted := NewTxResult(pending)
+		c.recorder.Submitted(call, &submitted)
+	}
+	tx, err := c.client.WaitForTransaction(ctx, pending.Hash)
+	if err != nil {
+		return nil, err
+	}
+	txResult := NewTxResult(tx)
+	if c.recorder != nil {
+		c.recorder.Committed(call, &txResult)
 	}
+	if !tx.Success {
+		return &txResult, txError(&txResult)
+	}
+	return &txResult, nil
+}
+
+func (c *AptosClient) CreateGame(p CreateGameParams) (*TxResult, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	startTimestamp, _ := strconv.ParseUint(p.StartTimestamp, 10, 64)
+	return c.submit(Call{Module: c.bingo, Function: "create_game", GameID: NoGame, Params: p},
+		argS(p.GameName), argU(startTimestamp), argU(p.MintPrice), argU(p.Interval),
+		argS(p.CollectionName), argS(p.CollectionDescription), argS(p.CollectionURI), argU(p.RoyaltyNumerator))
+}
 
-	txResult, err := UnmarshalTxResult(o)
-	return &txResult, err
+func (c *AptosClient) DrawNumber(p DrawNumberParams) (*TxResult, error) {
+	return c.submitAny(Call{Module: p.Module.or(c.bingo), Function: "draw_number", GameID: p.GameID, Params: p},
+		argI(p.GameID))
+}
+
+func (c *AptosClient) StartGame(p StartGameParams) (*TxResult, error) {
+	return c.submitAny(Call{Module: p.Module.or(c.bingo), Function: "start_game", GameID: p.GameID, Params: p},
+		argI(p.GameID))
+}
+
+func (c *AptosClient) CancelGame(p CancelGameParams) (*TxResult, error) {
+	return c.submit(Call{Module: p.Module.or(c.bingo), Function: "cancel_game", GameID: p.GameID, Params: p},
+		argI(p.GameID))
 }
@@ -0,0 +1,105 @@
+package smartcontract
+
+import (
+	"strconv"
+)
+
+type SnlJoinGameParams struct {
+	Module Module
+	GameID int
+	Uri    string
+	// PlayerPublicKey is the hex encoded ed25519 key of the player the join
+	// is sent from, the avatar is bought by and for their account.
+	PlayerPublicKey string
+}
+
+type SnlRollDiceParams struct {
+	Module        Module
+	GameID        int
+	AvatarAddress string
+	// PlayerPublicKey is the key of the owner of the avatar, roll_dice
+	// only rolls for the avatars of its signer.
+	PlayerPublicKey string
+}
+
+type SnlGameWonParams struct {
+	Module        Module
+	GameID        int
+	User          string
+	AvatarAddress string
+	Snakes        int
+	Ladders       int
+}
+
+// SnlCreateGame sends create_game of SNL.move, which takes the same arguments
+// as the bingo one.
+func (c *AptosClient) SnlCreateGame(p CreateGameParams) (*TxResult, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	startTimestamp, _ := strconv.ParseUint(p.StartTimestamp, 10, 64)
+	return c.submit(Call{Module: c.snl, Function: "create_game", GameID: NoGame, Params: p},
+		argS(p.GameName), argU(startTimestamp), argU(p.MintPrice), argU(p.Interval),
+		argS(p.CollectionName), argS(p.CollectionDescription), argS(p.CollectionURI), argU(p.RoyaltyNumerator))
+}
+
+// PrepareSnlJoinGame simulates join_game sent by the player of
+// p.PlayerPublicKey, who pays its gas, see PrepareJoinGame.
+func (c *AptosClient) PrepareSnlJoinGame(p SnlJoinGameParams) (*PlayerTx, error) {
+	return c.prepare(Call{Module: p.Module.or(c.snl), Function: "join_game", GameID: p.GameID, Params: p}, p.PlayerPublicKey, false,
+		argI(p.GameID), argS(p.Uri))
+}
+
+// NewSnlJoinTx rebuilds a PlayerTx PrepareSnlJoinGame returned, see
+// NewJoinTx.
+func NewSnlJoinTx(p SnlJoinGameParams, sender, rawTransaction string) *PlayerTx {
+	return &PlayerTx{
+		Call:           Call{Module: p.Module, Function: "join_game", GameID: p.GameID, Params: p},
+		Sender:         sender,
+		RawTransaction: rawTransaction,
+	}
+}
+
+func (c *AptosClient) SnlStartGame(p StartGameParams) (*TxResult, error) {
+	return c.submitAny(Call{Module: p.Module.or(c.snl), Function: "start_game", GameID: p.GameID, Params: p},
+		argI(p.GameID))
+}
+
+// PrepareSnlRollDice simulates roll_dice sent by the player of
+// p.PlayerPublicKey, who pays its gas, see PrepareJoinGame.
+func (c *AptosClient) PrepareSnlRollDice(p SnlRollDiceParams) (*PlayerTx, error) {
+	avatar, err := argA(p.AvatarAddress)
+	if err != nil {
+		return nil, err
+	}
+	return c.prepare(Call{Module: p.Module.or(c.snl), Function: "roll_dice", GameID: p.GameID, Params: p}, p.PlayerPublicKey, false,
+		argI(p.GameID), avatar)
+}
+
+// NewSnlRollTx rebuilds a PlayerTx PrepareSnlRollDice returned, see
+// NewJoinTx.
+func NewSnlRollTx(p SnlRollDiceParams, sender, rawTransaction string) *PlayerTx {
+	return &PlayerTx{
+		Call:           Call{Module: p.Module, Function: "roll_dice", GameID: p.GameID, Params: p},
+		Sender:         sender,
+		RawTransaction: rawTransaction,
+	}
+}
+
+func (c *AptosClient) SnlGameWon(p SnlGameWonParams) (*TxResult, error) {
+	user, err := argA(p.User)
+	if err != nil {
+		return nil, err
+	}
+	avatar, err := argA(p.AvatarAddress)
+	if err != nil {
+		return nil, err
+	}
+	return c.submit(Call{Module: p.Module.or(c.snl), Function: "game_won", GameID: p.GameID, Params: p},
+		argI(p.GameID), user, avatar, argI(p.Snakes), argI(p.Ladders))
+}
+
+func (c *AptosClient) SnlCancelGame(p CancelGameParams) (*TxResult, error) {
+	return c.submit(Call{Module: p.Module.or(c.snl), Function: "cancel_game", GameID: p.GameID, Params: p},
+		argI(p.GameID))
+}
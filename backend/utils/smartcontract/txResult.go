@@ -1,6 +1,10 @@
 package smartcontract
 
-import "encoding/json"
+import (
+	"VirtueGaming/utils/aptos"
+	"encoding/json"
+	"strconv"
+)
 
 func UnmarshalTxResult(data []byte) (TxResult, error) {
 	var r TxResult
@@ -17,13 +21,82 @@ type TxResult struct {
 }
 
 type Result struct {
-	TransactionHash string `json:"transaction_hash"`
-	GasUsed         int64  `json:"gas_used"`
-	GasUnitPrice    int64  `json:"gas_unit_price"`
-	Sender          string `json:"sender"`
-	SequenceNumber  int64  `json:"sequence_number"`
-	Success         bool   `json:"success"`
-	TimestampUs     int64  `json:"timestamp_us"`
-	Version         int64  `json:"version"`
-	VMStatus        string `json:"vm_status"`
+	TransactionHash string  `json:"transaction_hash"`
+	GasUsed         int64   `json:"gas_used"`
+	GasUnitPrice    int64   `json:"gas_unit_price"`
+	Sender          string  `json:"sender"`
+	SequenceNumber  int64   `json:"sequence_number"`
+	Success         bool    `json:"success"`
+	TimestampUs     int64   `json:"timestamp_us"`
+	Version         int64   `json:"version"`
+	VMStatus        string  `json:"vm_status"`
+	Events          []Event `json:"events,omitempty"`
+}
+
+// Event is an event emitted by a transaction, Data holds the event struct as
+// JSON with u64 fields encoded as strings.
+type Event struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// Event decodes the data of the first event of type eventType emitted by the
+// transaction into out. It reports false when there is no such event.
+func (r *TxResult) Event(eventType string, out interface{}) (bool, error) {
+	for _, e := range r.Result.Events {
+		if e.Type == eventType {
+			return true, json.Unmarshal(e.Data, out)
+		}
+	}
+	return false, nil
+}
+
+// objectTransferEvents are the types of the event 0x1::object emits when an
+// object changes owner: a module event on recent frameworks, an event handle
+// event before.
+var objectTransferEvents = []string{"0x1::object::Transfer", "0x1::object::TransferEvent"}
+
+// TransferredObject returns the address of the first object the transaction
+// transferred to owner, such as a token minted for them. It reports false
+// when there is none.
+func (r *TxResult) TransferredObject(owner string) (string, bool) {
+	for _, e := range r.Result.Events {
+		if e.Type != objectTransferEvents[0] && e.Type != objectTransferEvents[1] {
+			continue
+		}
+		var transfer struct {
+			Object string `json:"object"`
+			To     string `json:"to"`
+		}
+		if err := json.Unmarshal(e.Data, &transfer); err == nil && aptos.SameAddress(transfer.To, owner) {
+			return transfer.Object, true
+		}
+	}
+	return "", false
+}
+
+// NewTxResult converts a committed transaction returned by the node into the
+// TxResult shape the handlers use.
+func NewTxResult(tx *aptos.Transaction) TxResult {
+	events := make([]Event, len(tx.Events))
+	for i, e := range tx.Events {
+		events[i] = Event{Type: e.Type, Data: e.Data}
+	}
+	return TxResult{Result: Result{
+		TransactionHash: tx.Hash,
+		GasUsed:         parseInt(tx.GasUsed),
+		GasUnitPrice:    parseInt(tx.GasUnitPrice),
+		Sender:          tx.Sender,
+		SequenceNumber:  parseInt(tx.SequenceNumber),
+		Success:         tx.Success,
+		TimestampUs:     parseInt(tx.Timestamp),
+		Version:         parseInt(tx.Version),
+		VMStatus:        tx.VMStatus,
+		Events:          events,
+	}}
+}
+
+func parseInt(s string) int64 {
+	i, _ := strconv.ParseInt(s, 10, 64)
+	return i
 }
@@ -0,0 +1,206 @@
+package smartcontract
+
+import (
+	"VirtueGaming/utils/aptos"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// viewTimeout bounds all the reads of one game state.
+const viewTimeout = 30 * time.Second
+
+const aptosCoin = "0x1::aptos_coin::AptosCoin"
+
+// GameState is the state of a bingo or snakes and ladders game as stored on
+// chain.
+type GameState struct {
+	CardsSold         int
+	CollectionAddress string
+	// PrizePool is the balance of the game treasury in octas.
+	PrizePool  uint64
+	IsStarted  bool
+	IsFinished bool
+}
+
+// PrizePoolAPT is PrizePool in APT.
+func (s *GameState) PrizePoolAPT() float64 {
+	return float64(s.PrizePool) / aptos.OctasPerAPT
+}
+
+type GameStateParams struct {
+	Module Module
+	GameID int
+}
+
+// stateGame holds the fields of the Game struct of both modules that are not
+// exposed by a view function.
+type stateGame struct {
+	ResCap struct {
+		Account string `json:"account"`
+	} `json:"res_cap"`
+	IsStarted  bool `json:"is_started"`
+	IsFinished bool `json:"is_finished"`
+	// CardObjAdd lists the cards of a bingo game.
+	CardObjAdd []string `json:"card_obj_add"`
+	// NftObjAdd lists the avatars of a snakes and ladders game.
+	NftObjAdd []string `json:"nft_obj_add"`
+}
+
+// ErrCardNotInGame is returned by CardOwner for an address that is not a card
+// of the game.
+var ErrCardNotInGame = errors.New("card is not a card of the game")
+
+// ErrAvatarNotInGame is returned by AvatarOwner for an address that is not an
+// avatar of the game.
+var ErrAvatarNotInGame = errors.New("avatar is not an avatar of the game")
+
+type CardParams struct {
+	Module  Module
+	GameID  int
+	Address string
+}
+
+func (c *AptosClient) BingoGameState(p GameStateParams) (*GameState, error) {
+	return c.gameState(p.Module.or(c.bingo), p.GameID)
+}
+
+func (c *AptosClient) SnlGameState(p GameStateParams) (*GameState, error) {
+	return c.gameState(p.Module.or(c.snl), p.GameID)
+}
+
+func (c *AptosClient) gameState(m Module, gameID int) (*GameState, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), viewTimeout)
+	defer cancel()
+
+	game, err := c.findStateGame(ctx, m, gameID)
+	if err != nil {
+		return nil, err
+	}
+	id := strconv.Itoa(gameID)
+	var sold string
+	if err := c.view(ctx, m.Function("get_number_of_cards_sold"), nil, []interface{}{id}, &sold); err != nil {
+		return nil, err
+	}
+	cardsSold, err := strconv.Atoi(sold)
+	if err != nil {
+		return nil, fmt.Errorf("invalid number of cards sold %q", sold)
+	}
+	state := &GameState{CardsSold: cardsSold, IsStarted: game.IsStarted, IsFinished: game.IsFinished}
+	if err := c.view(ctx, m.Function("get_collection_address"), nil, []interface{}{id}, &state.CollectionAddress); err != nil {
+		return nil, err
+	}
+	if state.PrizePool, err = c.balance(ctx, game.ResCap.Account); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+func (c *AptosClient) CardOwner(p CardParams) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), viewTimeout)
+	defer cancel()
+
+	game, err := c.findStateGame(ctx, p.Module.or(c.bingo), p.GameID)
+	if err != nil {
+		return "", err
+	}
+	if !containsAddress(game.CardObjAdd, p.Address) {
+		return "", ErrCardNotInGame
+	}
+	return c.objectOwner(ctx, p.Address)
+}
+
+// AvatarOwner returns the owner of an avatar of a snakes and ladders game.
+func (c *AptosClient) AvatarOwner(p CardParams) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), viewTimeout)
+	defer cancel()
+
+	game, err := c.findStateGame(ctx, p.Module.or(c.snl), p.GameID)
+	if err != nil {
+		return "", err
+	}
+	if !containsAddress(game.NftObjAdd, p.Address) {
+		return "", ErrAvatarNotInGame
+	}
+	return c.objectOwner(ctx, p.Address)
+}
+
+func containsAddress(list []string, address string) bool {
+	for _, a := range list {
+		if aptos.SameAddress(a, address) {
+			return true
+		}
+	}
+	return false
+}
+
+// objectOwner reads the owner of the object at address.
+func (c *AptosClient) objectOwner(ctx context.Context, address string) (string, error) {
+	addr, err := aptos.ParseAddress(address)
+	if err != nil {
+		return "", err
+	}
+	var object struct {
+		Owner string `json:"owner"`
+	}
+	if err := c.client.AccountResource(ctx, addr, "0x1::object::ObjectCore", &object); err != nil {
+		return "", fmt.Errorf("owner of %s: %w", address, err)
+	}
+	return object.Owner, nil
+}
+
+// balance returns the APT balance of address in octas.
+func (c *AptosClient) balance(ctx context.Context, address string) (uint64, error) {
+	var balance string
+	if err := c.view(ctx, "0x1::coin::balance", []string{aptosCoin}, []interface{}{address}, &balance); err != nil {
+		return 0, err
+	}
+	octas, err := strconv.ParseUint(balance, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid balance %q", balance)
+	}
+	return octas, nil
+}
+
+// findStateGame reads the game from the State resource of the module. The
+// view functions abort on unknown games, so this also tells those apart.
+func (c *AptosClient) findStateGame(ctx context.Context, m Module, gameID int) (*stateGame, error) {
+	deployer, err := aptos.ParseAddress(m.Address)
+	if err != nil {
+		return nil, err
+	}
+	var state struct {
+		Games struct {
+			Data []struct {
+				Key   string    `json:"key"`
+				Value stateGame `json:"value"`
+			} `json:"data"`
+		} `json:"games"`
+	}
+	if err := c.client.AccountResource(ctx, deployer, m.ID()+"::State", &state); err != nil {
+		return nil, fmt.Errorf("%s::State: %w", m.ID(), err)
+	}
+	id := strconv.Itoa(gameID)
+	for _, g := range state.Games.Data {
+		if g.Key == id {
+			return &g.Value, nil
+		}
+	}
+	return nil, fmt.Errorf("game %d of %s: %w", gameID, m.ID(), ErrGameNotInitialized)
+}
+
+// view calls a view function returning a single value and decodes it into
+// out.
+func (c *AptosClient) view(ctx context.Context, function string, typeArgs []string, args []interface{}, out interface{}) error {
+	values, err := c.client.View(ctx, function, typeArgs, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", function, err)
+	}
+	if len(values) != 1 {
+		return fmt.Errorf("%s returned %d values", function, len(values))
+	}
+	return json.Unmarshal(values[0], out)
+}
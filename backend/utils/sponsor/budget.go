@@ -0,0 +1,178 @@
+package sponsor
+
+import (
+	"VirtueGaming/models"
+	"VirtueGaming/utils/aptos"
+	"VirtueGaming/utils/smartcontract"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultWalletBudget = aptos.OctasPerAPT / 20
+	defaultGameBudget   = 2 * aptos.OctasPerAPT
+)
+
+var (
+	ErrWalletBudgetExceeded = errors.New("sponsorship budget of the wallet exceeded")
+	ErrGameBudgetExceeded   = errors.New("sponsorship budget of the game exceeded")
+	ErrNotReserved          = errors.New("sponsorship is not waiting for a signature")
+	ErrExpired              = errors.New("sponsorship has expired")
+)
+
+// Budget caps the gas the backend pays for players: PerWallet over all the
+// games of a wallet, PerGame over all the players of a game. A sponsorship
+// counts with the max gas cost of its transaction from the moment it is
+// reserved, and with the gas it actually cost once it is settled.
+type Budget struct {
+	db        *gorm.DB
+	PerWallet uint64
+	PerGame   uint64
+}
+
+func New(db *gorm.DB) *Budget {
+	return &Budget{db: db, PerWallet: defaultWalletBudget, PerGame: defaultGameBudget}
+}
+
+// NewFromEnv reads SPONSOR_WALLET_BUDGET and SPONSOR_GAME_BUDGET, amounts
+// such as "0.05 APT". They default to 0.05 APT and 2 APT, 0 turns
+// sponsorship off.
+func NewFromEnv(db *gorm.DB) (*Budget, error) {
+	b := New(db)
+	for _, v := range []struct {
+		name string
+		dst  *uint64
+	}{
+		{"SPONSOR_WALLET_BUDGET", &b.PerWallet},
+		{"SPONSOR_GAME_BUDGET", &b.PerGame},
+	} {
+		s := strings.TrimSpace(os.Getenv(v.name))
+		if s == "" {
+			continue
+		}
+		amount, err := aptos.ParseAmount(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", v.name, err)
+		}
+		*v.dst = amount
+	}
+	return b, nil
+}
+
+// Reserve stores s as reserved for the max cost of tx, unless that puts the
+// wallet or the game over its budget. Concurrent reservations of the same
+// wallet or game are serialized with advisory locks. A join the player pays
+// for is stored the same way, at no cost and whatever the budgets.
+func (b *Budget) Reserve(s *models.Sponsorship, tx *smartcontract.PlayerTx) error {
+	s.WalletAddress = tx.Sender
+	s.FeePayer = tx.FeePayer
+	s.RawTransaction = tx.RawTransaction
+	s.ExpiresAt = tx.ExpiresAt
+	s.Status = models.SponsorshipStatusReserved
+	if !tx.Sponsored() {
+		s.Cost = 0
+		return b.db.Create(s).Error
+	}
+	s.Cost = int64(tx.MaxCost())
+	return b.db.Transaction(func(db *gorm.DB) error {
+		for _, key := range []string{"sponsor-wallet:" + s.WalletAddress, fmt.Sprintf("sponsor-game:%s:%d", s.Module, s.GameId)} {
+			if err := lock(db, key); err != nil {
+				return err
+			}
+		}
+		spent, err := spentBy(db.Where("wallet_address = ?", s.WalletAddress))
+		if err != nil {
+			return err
+		}
+		if spent+uint64(s.Cost) > b.PerWallet {
+			return ErrWalletBudgetExceeded
+		}
+		spent, err = spentBy(db.Where("module = ? AND game_id = ?", s.Module, s.GameId))
+		if err != nil {
+			return err
+		}
+		if spent+uint64(s.Cost) > b.PerGame {
+			return ErrGameBudgetExceeded
+		}
+		return db.Create(s).Error
+	})
+}
+
+// lock takes the advisory lock of key until the end of the transaction of db.
+// Only Postgres has advisory locks, SQLite, which the tests run on, lets one
+// transaction write at a time.
+func lock(db *gorm.DB, key string) error {
+	if db.Dialector.Name() != "postgres" {
+		return nil
+	}
+	return db.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", key).Error
+}
+
+// spentBy sums the cost of the sponsorships of scope that still count.
+func spentBy(scope *gorm.DB) (uint64, error) {
+	var total int64
+	err := scope.Model(&models.Sponsorship{}).
+		Where("status <> ?", models.SponsorshipStatusReleased).
+		Where("NOT (status = ? AND expires_at < ?)", models.SponsorshipStatusReserved, time.Now()).
+		Select("COALESCE(SUM(cost), 0)").
+		Scan(&total).Error
+	return uint64(total), err
+}
+
+// Find returns the reserved sponsorship id, ready to be submitted.
+func (b *Budget) Find(id uint) (*models.Sponsorship, error) {
+	var s models.Sponsorship
+	if err := b.db.First(&s, id).Error; err != nil {
+		return nil, err
+	}
+	if s.Status != models.SponsorshipStatusReserved {
+		return nil, ErrNotReserved
+	}
+	if time.Now().After(s.ExpiresAt) {
+		return nil, ErrExpired
+	}
+	return &s, nil
+}
+
+// Submitted marks s as sent. Only one request may send a reservation, the
+// others get ErrNotReserved.
+func (b *Budget) Submitted(s *models.Sponsorship) error {
+	res := b.db.Model(s).Where("status = ?", models.SponsorshipStatusReserved).
+		Update("status", models.SponsorshipStatusSubmitted)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotReserved
+	}
+	return nil
+}
+
+// Settle records what the committed transaction of s cost the backend. A
+// transaction that was never committed keeps its reserved cost.
+func (b *Budget) Settle(s *models.Sponsorship, tx *smartcontract.TxResult) error {
+	status := models.SponsorshipStatusConfirmed
+	if !tx.Result.Success {
+		status = models.SponsorshipStatusFailed
+	}
+	var cost int64
+	if s.FeePayer != "" {
+		cost = tx.Result.GasUsed * tx.Result.GasUnitPrice
+	}
+	return b.db.Model(s).Updates(map[string]interface{}{
+		"status":           status,
+		"transaction_hash": tx.Result.TransactionHash,
+		"cost":             cost,
+	}).Error
+}
+
+// Release gives the reservation of s back, for a transaction that was never
+// sent.
+func (b *Budget) Release(s *models.Sponsorship) error {
+	return b.db.Model(s).Update("status", models.SponsorshipStatusReleased).Error
+}
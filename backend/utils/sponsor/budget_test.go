@@ -0,0 +1,150 @@
+package sponsor
+
+import (
+	"VirtueGaming/models"
+	"VirtueGaming/utils/smartcontract"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const testModule = "0x1::bingov2"
+
+func newTestBudget(t *testing.T) *Budget {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&models.Sponsorship{}); err != nil {
+		t.Fatal(err)
+	}
+	b := New(db)
+	b.PerWallet = 100
+	b.PerGame = 250
+	return b
+}
+
+// joinTx is a join of sender costing up to cost octas, paid by the admin
+// when sponsored.
+func joinTx(sender string, cost uint64, sponsored bool) *smartcontract.PlayerTx {
+	tx := &smartcontract.PlayerTx{
+		Sender:         sender,
+		RawTransaction: "0x00",
+		MaxGasAmount:   cost,
+		GasUnitPrice:   1,
+		ExpiresAt:      time.Now().Add(time.Minute),
+	}
+	if sponsored {
+		tx.FeePayer = "0xad"
+	}
+	return tx
+}
+
+func reserve(b *Budget, gameID int, tx *smartcontract.PlayerTx) (*models.Sponsorship, error) {
+	s := &models.Sponsorship{Module: testModule, GameId: gameID}
+	return s, b.Reserve(s, tx)
+}
+
+func TestReserve(t *testing.T) {
+	b := newTestBudget(t)
+	tests := []struct {
+		name   string
+		gameID int
+		tx     *smartcontract.PlayerTx
+		want   error
+	}{
+		{"within the budgets", 1, joinTx("0xa", 60, true), nil},
+		{"over the wallet budget", 2, joinTx("0xa", 60, true), ErrWalletBudgetExceeded},
+		{"rest of the wallet budget", 2, joinTx("0xa", 40, true), nil},
+		{"another wallet", 1, joinTx("0xb", 100, true), nil},
+		{"over the game budget", 1, joinTx("0xc", 100, true), ErrGameBudgetExceeded},
+		// A join the player pays for costs the backend nothing.
+		{"not sponsored", 1, joinTx("0xa", 1000, false), nil},
+	}
+	for _, tt := range tests {
+		s, err := reserve(b, tt.gameID, tt.tx)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+			continue
+		}
+		if err == nil && (s.ID == 0 || s.Status != models.SponsorshipStatusReserved) {
+			t.Errorf("%s: stored as %d with status %q", tt.name, s.ID, s.Status)
+		}
+	}
+}
+
+func TestReserveReleased(t *testing.T) {
+	b := newTestBudget(t)
+	s, err := reserve(b, 1, joinTx("0xa", 100, true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reserve(b, 1, joinTx("0xa", 100, true)); !errors.Is(err, ErrWalletBudgetExceeded) {
+		t.Fatalf("got %v, want %v", err, ErrWalletBudgetExceeded)
+	}
+	if err := b.Release(s); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reserve(b, 1, joinTx("0xa", 100, true)); err != nil {
+		t.Errorf("reserve after the release: %v", err)
+	}
+}
+
+func TestReserveExpired(t *testing.T) {
+	b := newTestBudget(t)
+	tx := joinTx("0xa", 100, true)
+	tx.ExpiresAt = time.Now().Add(-time.Second)
+	s, err := reserve(b, 1, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Find(s.ID); !errors.Is(err, ErrExpired) {
+		t.Errorf("find: got %v, want %v", err, ErrExpired)
+	}
+	// An expired reservation was never signed and no longer counts.
+	if _, err := reserve(b, 1, joinTx("0xa", 100, true)); err != nil {
+		t.Errorf("reserve after the expiry: %v", err)
+	}
+}
+
+func TestSubmitAndSettle(t *testing.T) {
+	b := newTestBudget(t)
+	s, err := reserve(b, 1, joinTx("0xa", 100, true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, err := b.Find(s.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Submitted(found); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Submitted(found); !errors.Is(err, ErrNotReserved) {
+		t.Errorf("second submit: got %v, want %v", err, ErrNotReserved)
+	}
+	if _, err := b.Find(s.ID); !errors.Is(err, ErrNotReserved) {
+		t.Errorf("find once submitted: got %v, want %v", err, ErrNotReserved)
+	}
+
+	tx := &smartcontract.TxResult{Result: smartcontract.Result{TransactionHash: "0xabc", Success: true, GasUsed: 30, GasUnitPrice: 1}}
+	if err := b.Settle(found, tx); err != nil {
+		t.Fatal(err)
+	}
+	var settled models.Sponsorship
+	if err := b.db.First(&settled, s.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if settled.Status != models.SponsorshipStatusConfirmed || settled.Cost != 30 || settled.TransactionHash != "0xabc" {
+		t.Errorf("settled with status %q, cost %d and hash %q", settled.Status, settled.Cost, settled.TransactionHash)
+	}
+	// The gas it did not use is given back to the wallet.
+	if _, err := reserve(b, 1, joinTx("0xa", 70, true)); err != nil {
+		t.Errorf("reserve the rest of the budget: %v", err)
+	}
+}
@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"slices"
+)
+
+const (
+	stripTickets = 6
+	// stripAttempts bounds the random column counts tried before falling back
+	// to counts that always work, see stripColumnCounts.
+	stripAttempts = 100
+)
+
+// GenerateStrip returns a strip from a random seed.
+func GenerateStrip() [6][3][9]int {
+	return GenerateStripFromSeed(RandomTicketSeed())
+}
+
+// GenerateStripFromSeed returns six tickets that together hold every number
+// from 1 to 90 exactly once. Like the tickets of GenerateFromSeed, column c
+// holds numbers from c*10+1 to c*10+10, sorted top to bottom, every row holds
+// 5 numbers and every column of a ticket 1 to 3.
+func GenerateStripFromSeed(seed TicketSeed) [6][3][9]int {
+	r := newSeedStream(seed)
+	counts := stripColumnCounts(r)
+
+	var strip [6][3][9]int
+	for t := range strip {
+		layout := ticketLayout(counts[t], r)
+		for col := 0; col < 9; col++ {
+			for row := 0; row < 3; row++ {
+				if layout[row][col] {
+					strip[t][row][col] = -1
+				}
+			}
+		}
+	}
+	for col := 0; col < 9; col++ {
+		numbers := r.Perm(10)
+		for t := range strip {
+			dealt := numbers[:counts[t][col]]
+			numbers = numbers[counts[t][col]:]
+			slices.Sort(dealt)
+			for row := 0; row < 3; row++ {
+				if strip[t][row][col] == -1 {
+					strip[t][row][col] = col*10 + dealt[0] + 1
+					dealt = dealt[1:]
+				}
+			}
+		}
+	}
+	return strip
+}
+
+// stripColumnCounts decides how many numbers every ticket gets in every
+// column: 1 to 3, 10 per column over the strip and 15 per ticket. Every ticket
+// column gets one number, the 4 left in each column are spread at random. A
+// random spread can corner itself, so after stripAttempts the spread is
+// limited to one extra number per ticket column, which the greedy choice of
+// the neediest tickets always completes.
+func stripColumnCounts(r *seedStream) [6][9]int {
+	for attempt := 0; attempt < stripAttempts; attempt++ {
+		if counts, ok := spreadColumnCounts(r, 3); ok {
+			return counts
+		}
+	}
+	counts, _ := spreadColumnCounts(r, 2)
+	return counts
+}
+
+func spreadColumnCounts(r *seedStream, max int) ([6][9]int, bool) {
+	var counts [6][9]int
+	var need [stripTickets]int
+	for t := range counts {
+		for col := range counts[t] {
+			counts[t][col] = 1
+		}
+		need[t] = 15 - 9
+	}
+	for _, col := range r.Perm(9) {
+		for extra := 0; extra < 10-stripTickets; extra++ {
+			best := -1
+			for _, t := range r.Perm(stripTickets) {
+				if need[t] == 0 || counts[t][col] == max {
+					continue
+				}
+				if best == -1 || need[t] > need[best] {
+					best = t
+				}
+			}
+			if best == -1 {
+				return counts, false
+			}
+			counts[best][col]++
+			need[best]--
+		}
+	}
+	return counts, true
+}
+
+// ticketLayout picks the rows of the numbers of a ticket with counts numbers
+// per column, 5 per row. Filling the fullest columns first, each in the rows
+// with the most room left, always succeeds.
+func ticketLayout(counts [9]int, r *seedStream) [3][9]bool {
+	var layout [3][9]bool
+	room := [3]int{5, 5, 5}
+	cols := r.Perm(9)
+	slices.SortStableFunc(cols, func(a, b int) int { return counts[b] - counts[a] })
+	for _, col := range cols {
+		rows := r.Perm(3)
+		slices.SortStableFunc(rows, func(a, b int) int { return room[b] - room[a] })
+		for _, row := range rows[:counts[col]] {
+			layout[row][col] = true
+			room[row]--
+		}
+	}
+	return layout
+}
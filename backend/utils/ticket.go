@@ -1,193 +1,112 @@
 package utils
 
 import (
-	"math/rand"
+	"fmt"
+	"slices"
 	"strconv"
-	"time"
+	"strings"
 )
 
-type Ticket struct {
-	entries [3][9]int
-}
-
+// Generate returns a ticket from a random seed, for tickets nobody needs to
+// verify later.
 func Generate() [3][9]int {
-	rand.Seed(time.Now().UnixNano())
-	t := &Ticket{entries: [3][9]int{}}
-	numbers := make(map[int][]int)
-	for i := 0; i < 9; i++ {
-		var group []int
-		for j := 0; j < 10; j++ {
-			group = append(group, i*10+j+1)
-		}
-		numbers[i] = group
-	}
+	return GenerateFromSeed(RandomTicketSeed())
+}
 
-	// take one row values because it will be same for all in the start
-	rows := getRowValues(0, t)
-	for colIndex := range rows {
-		// taking the length of numbers[colIndex] because we are deleting the values once inserted
-		// to avoid collision we take only remaining index
-		randomIndex := rand.Intn(len(numbers[colIndex]))
-		numberToInsert := numbers[colIndex][randomIndex]
-
-		// get a random row which is incomplete
-		possibleRow := []int{0, 1, 2}
-		var incompleteRows []int
-
-		for _, v := range possibleRow {
-			if !IsRowCompleted(v, t) {
-				incompleteRows = append(incompleteRows, v)
+// GenerateFromSeed returns the ticket of seed. The same seed always gives the
+// same ticket, see VerifyTicket.
+//
+// The ticket has 5 numbers per row and 1 to 3 per column, column c holding
+// numbers from c*10+1 to c*10+10 sorted top to bottom. Every layout of the
+// cells is equally likely, and so is every choice of numbers for a layout:
+// the layout is drawn column by column, each option weighted by the number of
+// layouts it can still complete to, so no attempt is ever thrown away and
+// the work is fixed. Every choice is drawn from seed by a seedStream, whose
+// procedure is fixed so a published ticket can be checked with other tools.
+func GenerateFromSeed(seed TicketSeed) [3][9]int {
+	r := newSeedStream(seed)
+	var ticket [3][9]int
+	layout := randomLayout(r)
+	for col := 0; col < 9; col++ {
+		var rows []int
+		for row := 0; row < 3; row++ {
+			if layout[row][col] {
+				rows = append(rows, row)
 			}
 		}
-
-		// get random row which is not completed from 0,1,2
-		randomIncompleteRowIndex := rand.Intn(len(possibleRow))
-		randomRow := possibleRow[randomIncompleteRowIndex]
-
-		// if the location is 0 and column is not completed
-		if !IsColumnCompleted(colIndex, t) && t.entries[randomRow][colIndex] == 0 {
-
-			// insert the value at the chosen location
-			insertValue(t, randomRow, colIndex, numberToInsert)
-
-			// remove the inserted numbers from the grouped number map
-			groupToUpdate := numbers[colIndex]
-			groupToUpdate = append(groupToUpdate[:randomIndex], groupToUpdate[randomIndex+1:]...)
-			numbers[colIndex] = groupToUpdate
+		numbers := r.Perm(10)[:len(rows)]
+		slices.Sort(numbers)
+		for i, row := range rows {
+			ticket[row][col] = col*10 + numbers[i] + 1
 		}
 	}
-	fillRecursively(t, numbers)
-	return t.entries
+	return ticket
 }
 
-func SortTicket(ticket *Ticket) [3][9]int {
-	t := &ticket.entries
-
-	for colIndex := 0; colIndex < 9; colIndex++ {
-		// check all 3 possible row index is filled
-		if t[0][colIndex] != 0 && t[1][colIndex] != 0 && t[2][colIndex] != 0 {
-			for i := 0; i < 2; i++ {
-				for j := i + 1; j < 3; j++ {
-					if t[i][colIndex] > t[j][colIndex] {
-						temp := t[i][colIndex]
-						t[i][colIndex] = t[j][colIndex]
-						t[j][colIndex] = temp
+// columnOptions are the cells a column may fill, one bit per row: every non
+// empty subset of the three rows.
+var columnOptions = []int{1, 2, 3, 4, 5, 6, 7}
+
+// layoutWays[col][a][b][c] is the number of ways to fill columns col to 8 once
+// the rows hold a, b and c numbers, such that every row ends with 5.
+var layoutWays = countLayouts()
+
+func countLayouts() *[10][6][6][6]int64 {
+	var ways [10][6][6][6]int64
+	ways[9][5][5][5] = 1
+	for col := 8; col >= 0; col-- {
+		for a := 0; a <= 5; a++ {
+			for b := 0; b <= 5; b++ {
+				for c := 0; c <= 5; c++ {
+					for _, o := range columnOptions {
+						na, nb, nc := a+o&1, b+o>>1&1, c+o>>2&1
+						if na <= 5 && nb <= 5 && nc <= 5 {
+							ways[col][a][b][c] += ways[col+1][na][nb][nc]
+						}
 					}
 				}
 			}
-		} else if t[0][colIndex] != 0 && t[1][colIndex] != 0 && t[2][colIndex] == 0 {
-			// only first & second row is populated
-			if t[0][colIndex] > t[1][colIndex] {
-				temp := t[0][colIndex]
-				t[0][colIndex] = t[1][colIndex]
-				t[1][colIndex] = temp
-			}
-		} else if t[0][colIndex] != 0 && t[1][colIndex] == 0 && t[2][colIndex] != 0 {
-			// only first and third is populated
-			if t[0][colIndex] > t[2][colIndex] {
-				temp := t[0][colIndex]
-				t[0][colIndex] = t[2][colIndex]
-				t[2][colIndex] = temp
-			}
-		} else if t[0][colIndex] == 0 && t[1][colIndex] != 0 && t[2][colIndex] != 0 {
-			// only second and third is populated
-			if t[1][colIndex] > t[2][colIndex] {
-				temp := t[1][colIndex]
-				t[1][colIndex] = t[2][colIndex]
-				t[2][colIndex] = temp
-			}
 		}
 	}
-	return *t
+	return &ways
 }
 
-func fillRecursively(ticket *Ticket, numbers map[int][]int) {
-	rand.Seed(time.Now().UnixNano())
-	cValues := getColumnValues(0, ticket)
-	rValues := getRowValues(0, ticket)
-
-	for rIndex := range cValues {
-		for cIndex := range rValues {
-			// get a random index
-			randomIndex := rand.Intn(len(numbers[cIndex]))
-			numberToInsert := numbers[cIndex][randomIndex]
-
-			//Random chance for generating variants of the ticket
-			isSet := rand.Float32() > 0.5
-
-			if !isSet &&
-				!IsTicketCompleted(ticket) &&
-				!IsRowCompleted(rIndex, ticket) &&
-				!IsColumnCompleted(cIndex, ticket) &&
-				ticket.entries[rIndex][cIndex] == 0 {
-				insertValue(ticket, rIndex, cIndex, numberToInsert)
-
-				// remove the inserted numbers from the grouped number map
-				groupToUpdate := numbers[cIndex]
-				groupToUpdate = append(groupToUpdate[:randomIndex], groupToUpdate[randomIndex+1:]...)
-				numbers[cIndex] = groupToUpdate
-			}
-		}
-	}
-	if !IsTicketCompleted(ticket) {
-		fillRecursively(ticket, numbers)
-	} else {
-		SortTicket(ticket)
-	}
+// TicketLayouts is the number of distinct layouts a ticket can have.
+func TicketLayouts() int64 {
+	return layoutWays[0][0][0][0]
 }
 
-func getNumberOfEntries(ticket *Ticket) int {
-	count := 0
-	for _, r := range ticket.entries {
-		for _, c := range r {
-			if c != 0 {
-				count++
+// randomLayout draws one of the TicketLayouts layouts, all equally likely.
+func randomLayout(r *seedStream) [3][9]bool {
+	var layout [3][9]bool
+	var a, b, c int
+	for col := 0; col < 9; col++ {
+		pick := r.Int64N(layoutWays[col][a][b][c])
+		for _, o := range columnOptions {
+			na, nb, nc := a+o&1, b+o>>1&1, c+o>>2&1
+			if na > 5 || nb > 5 || nc > 5 {
+				continue
 			}
+			if pick < layoutWays[col+1][na][nb][nc] {
+				layout[0][col], layout[1][col], layout[2][col] = o&1 == 1, o&2 == 2, o&4 == 4
+				a, b, c = na, nb, nc
+				break
+			}
+			pick -= layoutWays[col+1][na][nb][nc]
 		}
 	}
-	return count
-}
-
-func IsTicketCompleted(ticket *Ticket) bool {
-	return getNumberOfEntries(ticket) == 15
-}
-
-func IsRowCompleted(rowIndex int, ticket *Ticket) bool {
-	rowValues := getRowValues(rowIndex, ticket)
-	count := 0
-	for _, v := range rowValues {
-		if v != 0 {
-			count++
-		}
-	}
-	return count == 5
+	return layout
 }
 
-func IsColumnCompleted(columnIndex int, ticket *Ticket) bool {
-	colValues := getColumnValues(columnIndex, ticket)
-	count := 0
-	for _, v := range colValues {
-		if v != 0 {
-			count++
+// TicketLayout returns the cells of ticket that hold a number.
+func TicketLayout(ticket [3][9]int) [3][9]bool {
+	var layout [3][9]bool
+	for row := range ticket {
+		for col := range ticket[row] {
+			layout[row][col] = ticket[row][col] != 0
 		}
 	}
-	return count == 3
-}
-
-func getRowValues(rowIndex int, ticket *Ticket) [9]int {
-	return ticket.entries[rowIndex]
-}
-
-func getColumnValues(columnIndex int, ticket *Ticket) []int {
-	var columns []int
-	for _, r := range ticket.entries {
-		columns = append(columns, r[columnIndex])
-	}
-	return columns
-}
-
-func insertValue(ticket *Ticket, rowIndex int, colIndex int, value int) {
-	ticket.entries[rowIndex][colIndex] = value
+	return layout
 }
 
 // flattenTicket flattens the optimized ticket into a single slice
@@ -235,3 +154,53 @@ func OptimizeTicket(ticket [][]string) [][]string {
 
 	return optimizedTicket
 }
+
+// ParseFlatTicket reads back a ticket stored as the comma separated output of
+// FlattenTicket.
+func ParseFlatTicket(flat string) ([3][9]int, error) {
+	var ticket [3][9]int
+	values := strings.Split(flat, ",")
+	if len(values) != 27 {
+		return ticket, fmt.Errorf("ticket must have 27 cells, got %d", len(values))
+	}
+	for i, v := range values {
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return ticket, fmt.Errorf("invalid ticket cell %q: %w", v, err)
+		}
+		ticket[i/9][i%9] = n
+	}
+	return ticket, nil
+}
+
+// LineDrawn mirrors check_prize of bingov2 on a row of ChainRows: past up to
+// 4 blanks, the first 5 numbers of the row must be drawn. A row with fewer
+// than 5 numbers never wins, check_prize aborts on it.
+func LineDrawn(row []int, drawn map[int]bool) bool {
+	var numbers []int
+	for i, n := range row {
+		if n == 0 && i-len(numbers) < 4 {
+			continue
+		}
+		numbers = append(numbers, n)
+	}
+	if len(numbers) < 5 {
+		return false
+	}
+	for _, n := range numbers[:5] {
+		if !drawn[n] {
+			return false
+		}
+	}
+	return true
+}
+
+// IsRowDrawn reports whether every number of a ticket row has been drawn.
+func IsRowDrawn(row [9]int, drawn map[int]bool) bool {
+	for _, n := range row {
+		if n != 0 && !drawn[n] {
+			return false
+		}
+	}
+	return true
+}
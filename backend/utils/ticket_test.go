@@ -0,0 +1,184 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// ticketsTested is the number of tickets drawn by the uniformity tests, enough
+// to expect a few hundred in every cell of the chi-square tests.
+const ticketsTested = 200000
+
+// maxZ is the z-score, (chi2 - df) / sqrt(2 df), above which a distribution
+// is reported as not uniform.
+const maxZ = 4
+
+// testTickets generates n tickets from fixed seeds, so a failure reproduces.
+func testTickets(n int) [][3][9]int {
+	tickets := make([][3][9]int, n)
+	for i := range tickets {
+		var seed TicketSeed
+		binary.LittleEndian.PutUint64(seed[:], uint64(i))
+		tickets[i] = GenerateFromSeed(seed)
+	}
+	return tickets
+}
+
+func checkChiSquare(t *testing.T, name string, observed []int, expected []float64) {
+	t.Helper()
+	chi2 := 0.0
+	for i := range observed {
+		d := float64(observed[i]) - expected[i]
+		chi2 += d * d / expected[i]
+	}
+	df := float64(len(observed) - 1)
+	if z := (chi2 - df) / math.Sqrt(2*df); math.Abs(z) > maxZ {
+		t.Errorf("%s: chi2 %.1f with %.0f degrees of freedom, z %.2f", name, chi2, df, z)
+	}
+}
+
+func TestTicketLayouts(t *testing.T) {
+	if got := TicketLayouts(); got != 735210 {
+		t.Errorf("TicketLayouts() = %d, want 735210", got)
+	}
+}
+
+func TestGenerateFromSeedValid(t *testing.T) {
+	for _, ticket := range testTickets(10000) {
+		if err := NinetyBall.Validate(TicketCard(ticket)); err != nil {
+			t.Fatalf("invalid ticket %v: %s", ticket, err)
+		}
+	}
+}
+
+func TestGenerateFromSeedDeterministic(t *testing.T) {
+	seed := RandomTicketSeed()
+	if a, b := GenerateFromSeed(seed), GenerateFromSeed(seed); a != b {
+		t.Errorf("seed gave %v then %v", a, b)
+	}
+}
+
+// goldenSeed is the seed the golden tests draw from. Their cards must never
+// change: a card is verified by drawing it again from its seed, see
+// VerifyTicket.
+var goldenSeed = NewTicketSeed([]byte("golden game secret"), "0x1::bingov2", 1, "0xa", 0)
+
+func TestSeedStream(t *testing.T) {
+	s := newSeedStream(goldenSeed)
+	block := sha256.Sum256(append(goldenSeed[:], 0, 0, 0, 0, 0, 0, 0, 0))
+	if got, want := s.uint64(), binary.BigEndian.Uint64(block[:8]); got != want {
+		t.Errorf("first value %d, want %d", got, want)
+	}
+	if got, want := s.Perm(10), []int{3, 1, 6, 5, 4, 2, 8, 7, 0, 9}; !slices.Equal(got, want) {
+		t.Errorf("Perm(10) = %v, want %v", got, want)
+	}
+}
+
+func TestGenerateFromSeedGolden(t *testing.T) {
+	want := [3][9]int{
+		{0, 11, 23, 32, 48, 0, 61, 0, 0},
+		{2, 16, 0, 38, 0, 0, 65, 74, 0},
+		{0, 19, 24, 40, 0, 60, 0, 0, 85},
+	}
+	if got := GenerateFromSeed(goldenSeed); got != want {
+		t.Errorf("GenerateFromSeed() = %v, want %v", got, want)
+	}
+	tests := []struct {
+		format CardFormat
+		want   Card
+	}{
+		{SeventyFiveBall, Card{
+			{1, 29, 39, 53, 71},
+			{6, 25, 43, 57, 75},
+			{15, 28, 0, 50, 61},
+			{5, 17, 34, 54, 73},
+			{10, 21, 33, 60, 65},
+		}},
+		{ThirtyBall, Card{
+			{1, 12, 21},
+			{7, 17, 26},
+			{9, 19, 29},
+		}},
+	}
+	for _, tt := range tests {
+		if got := tt.format.Generate(goldenSeed); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.format.Name(), got, tt.want)
+		}
+	}
+}
+
+func TestGenerateFromSeedUniform(t *testing.T) {
+	if testing.Short() {
+		t.Skip("draws many tickets")
+	}
+	tickets := testTickets(ticketsTested)
+
+	// The layouts are grouped by their first three columns: a group is
+	// expected in the share of the layouts that start with it.
+	groups := make([]int, 7*7*7)
+	expectedGroups := make([]float64, len(groups))
+	for g := range expectedGroups {
+		var rows [3]int
+		for col := 0; col < 3; col++ {
+			o := columnOptions[g/pow7(col)%7]
+			rows[0] += o & 1
+			rows[1] += o >> 1 & 1
+			rows[2] += o >> 2 & 1
+		}
+		share := float64(layoutWays[3][rows[0]][rows[1]][rows[2]]) / float64(TicketLayouts())
+		expectedGroups[g] = ticketsTested * share
+	}
+
+	var numbers [9][]int
+	for col := range numbers {
+		numbers[col] = make([]int, 10)
+	}
+	for _, ticket := range tickets {
+		layout := TicketLayout(ticket)
+		g := 0
+		for col := 0; col < 3; col++ {
+			o := 0
+			for row := 0; row < 3; row++ {
+				if layout[row][col] {
+					o |= 1 << row
+				}
+			}
+			g += (o - 1) * pow7(col)
+		}
+		groups[g]++
+		for row := 0; row < 3; row++ {
+			for col := 0; col < 9; col++ {
+				if n := ticket[row][col]; n != 0 {
+					numbers[col][n-col*10-1]++
+				}
+			}
+		}
+	}
+	checkChiSquare(t, "layouts by first three columns", groups, expectedGroups)
+
+	// Within a column every number is as likely as the others.
+	for col := range numbers {
+		sum := 0
+		for _, c := range numbers[col] {
+			sum += c
+		}
+		expected := make([]float64, 10)
+		for i := range expected {
+			expected[i] = float64(sum) / 10
+		}
+		checkChiSquare(t, fmt.Sprintf("numbers %d-%d", col*10+1, col*10+10), numbers[col], expected)
+	}
+}
+
+func pow7(n int) int {
+	p := 1
+	for ; n > 0; n-- {
+		p *= 7
+	}
+	return p
+}
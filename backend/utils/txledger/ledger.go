@@ -0,0 +1,137 @@
+package txledger
+
+import (
+	"VirtueGaming/models"
+	"VirtueGaming/utils/indexer"
+	"VirtueGaming/utils/smartcontract"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultPollInterval = 10 * time.Second
+	// pendingGrace leaves the transactions the client is still waiting for
+	// to the client.
+	pendingGrace = 30 * time.Second
+	// expireAfter is well past the expiration the transactions are built
+	// with, a transaction still unknown by then will never be committed.
+	expireAfter = 5 * time.Minute
+)
+
+// Ledger records the transactions of an AptosClient in the transactions
+// table.
+type Ledger struct {
+	db           *gorm.DB
+	PollInterval time.Duration
+}
+
+func New(db *gorm.DB) *Ledger {
+	return &Ledger{db: db, PollInterval: defaultPollInterval}
+}
+
+func (l *Ledger) Submitted(call smartcontract.Call, tx *smartcontract.TxResult) {
+	params, err := json.Marshal(call.Params)
+	if err != nil {
+		params = []byte("null")
+	}
+	record := models.Transaction{
+		Hash:           tx.Result.TransactionHash,
+		Module:         call.Module.ID(),
+		Function:       call.Function,
+		Params:         string(params),
+		Sender:         tx.Result.Sender,
+		SequenceNumber: tx.Result.SequenceNumber,
+		Status:         models.TransactionStatusPending,
+	}
+	if call.GameID != smartcontract.NoGame {
+		record.GameId = &call.GameID
+	}
+	if err := l.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&record).Error; err != nil {
+		logrus.Errorf("failed to record transaction %s: %s", record.Hash, err)
+	}
+}
+
+func (l *Ledger) Committed(call smartcontract.Call, tx *smartcontract.TxResult) {
+	if err := l.commit(call.Module, tx); err != nil {
+		logrus.Errorf("failed to record transaction %s: %s", tx.Result.TransactionHash, err)
+	}
+}
+
+// commit stores the outcome of a committed transaction. create_game calls
+// get linked to the game they created.
+func (l *Ledger) commit(module smartcontract.Module, tx *smartcontract.TxResult) error {
+	r := tx.Result
+	status := models.TransactionStatusConfirmed
+	if !r.Success {
+		status = models.TransactionStatusFailed
+	}
+	values := map[string]interface{}{
+		"status":         status,
+		"vm_status":      r.VMStatus,
+		"version":        r.Version,
+		"gas_used":       r.GasUsed,
+		"gas_unit_price": r.GasUnitPrice,
+		"gas_cost":       r.GasUsed * r.GasUnitPrice,
+	}
+	var created indexer.CreateGameEvent
+	if found, _ := tx.Event(module.EventType("CreateGameEvent"), &created); found {
+		values["game_id"] = created.GameID
+	}
+	return l.db.Model(&models.Transaction{}).Where("hash = ?", r.TransactionHash).Updates(values).Error
+}
+
+// TxLookup finds committed transactions, see AptosClient.Transaction.
+type TxLookup interface {
+	Transaction(ctx context.Context, hash string) (*smartcontract.TxResult, error)
+}
+
+// Poll settles the transactions left pending, because the process stopped
+// or gave up waiting for them, every PollInterval until ctx is done.
+func (l *Ledger) Poll(ctx context.Context, chain TxLookup) {
+	ticker := time.NewTicker(l.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := l.poll(ctx, chain, time.Now()); err != nil {
+			logrus.Error("failed to poll pending transactions: ", err)
+		}
+	}
+}
+
+func (l *Ledger) poll(ctx context.Context, chain TxLookup, now time.Time) error {
+	var pending []models.Transaction
+	if err := l.db.Model(&models.Transaction{}).
+		Where("status = ? AND created_at < ?", models.TransactionStatusPending, now.Add(-pendingGrace)).
+		Find(&pending).Error; err != nil {
+		return err
+	}
+	for _, p := range pending {
+		tx, err := chain.Transaction(ctx, p.Hash)
+		if err != nil {
+			logrus.Errorf("failed to look up transaction %s: %s", p.Hash, err)
+			continue
+		}
+		if tx == nil {
+			if p.CreatedAt.Before(now.Add(-expireAfter)) {
+				err = l.db.Model(&models.Transaction{}).Where("hash = ?", p.Hash).
+					Update("status", models.TransactionStatusExpired).Error
+			}
+		} else {
+			module, _ := smartcontract.ParseModule(p.Module)
+			err = l.commit(module, tx)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}